@@ -17,26 +17,44 @@
 package fortuna
 
 import (
+	"context"
 	"crypto/aes"
+	"errors"
+	"fmt"
 	"hash"
+	"math/bits"
 	"os"
 	"sync"
 	"time"
-
-	"github.com/seehuhn/sha256d"
 )
 
+// Default values for the settings which can be changed using the
+// options of NewAccumulatorWithOptions().
 const (
 	numPools               = 32
 	minPoolSize            = 32
 	minReseedInterval      = 100 * time.Millisecond
 	seedFileUpdateInterval = 10 * time.Minute
+	maxSources             = 256
 )
 
+// RandomGenerator is the interface an Accumulator uses to access its
+// underlying pseudo random number generator.  The Fortuna Generator,
+// the ChaChaGenerator, the CombinedGenerator, and the NIST SP 800-90A
+// generators CTRDRBG, HMACDRBG and HashDRBG implement this interface.
+type RandomGenerator interface {
+	// ReseedE mixes seed into the state of the generator.
+	ReseedE(seed []byte) error
+
+	// Fill fills dst with pseudo random bytes.
+	Fill(dst []byte) error
+}
+
 // Accumulator holds the state of one instance of the Fortuna random
 // number generator.  Randomness can be extracted using the
 // RandomData() and Read() methods.  Entropy from the environment
-// should be submitted regularly using channels allocated by the
+// should be submitted regularly using Source handles allocated by the
+// NewSource() method, or using channels allocated by the
 // NewEntropyDataSink() or NewEntropyTimeStampSink() methods.
 //
 // It is safe to access an Accumulator object concurrently from
@@ -45,19 +63,39 @@ type Accumulator struct {
 	seedFile     *os.File
 	stopAutoSave chan<- bool
 
-	genMutex sync.Mutex
-	gen      *Generator
-
-	poolMutex    sync.Mutex
-	reseedCount  int
-	nextReseed   time.Time
-	pool         [numPools]hash.Hash
-	poolZeroSize int
-
-	sourceMutex sync.Mutex
-	nextSource  uint8
-	stopSources chan bool
-	sources     sync.WaitGroup
+	genMutex        sync.Mutex
+	gen             RandomGenerator
+	intBuf          [8]byte
+	onHealthFailure func(error)
+	healthFailed    bool
+
+	// locked memory for the seeds, see WithSecureMemory().  The
+	// buffers are nil if ordinary memory is used, and are protected
+	// by genMutex.
+	mem      *secureRegion
+	poolSeed []byte
+	fileSeed []byte
+
+	poolMutex         sync.Mutex
+	reseedCount       uint64
+	nextReseed        time.Time
+	pool              []hash.Hash
+	newHash           func() hash.Hash
+	poolZeroSize      int
+	minPoolSize       int
+	minReseedInterval time.Duration
+
+	sourceMutex   sync.Mutex
+	nextSource    uint64
+	activeSources int
+	maxSources    int
+	stopSources   chan bool
+	sources       sync.WaitGroup
+
+	// cancelled by Close(), to stop the collectors added using
+	// AddCollector()
+	collectCtx     context.Context
+	stopCollectors context.CancelFunc
 }
 
 // NewRNG allocates a new instance of the Fortuna random number
@@ -95,18 +133,72 @@ var (
 // NewRNG(seedFileName).  See the documentation for NewRNG() for more
 // information.
 func NewAccumulator(newCipher NewCipher, seedFileName string) (*Accumulator, error) {
+	return NewAccumulatorWithOptions(WithCipher(newCipher),
+		WithSeedFile(seedFileName))
+}
+
+// NewAccumulatorWithOptions allocates a new instance of the Fortuna
+// random number generator, using the settings given by opts.  If the
+// combination of options is invalid, an error wrapping
+// ErrInvalidOption is returned.  If the power-on self test fails, an
+// error wrapping ErrSelfTest is returned.  If the generator cannot be
+// initialised, the error from NewGeneratorE() is returned.  Without
+// any options, the result is the same as for NewRNG("").  See the
+// documentation for NewRNG() for more information.
+func NewAccumulatorWithOptions(opts ...Option) (*Accumulator, error) {
+	cfg := defaultConfig()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	err := cfg.validate()
+	if err != nil {
+		return nil, err
+	}
+	err = powerOnSelfTest()
+	if err != nil {
+		return nil, err
+	}
+
+	gen, err := cfg.newGenerator(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.healthTest {
+		h, ok := gen.(interface{ EnableHealthTest() })
+		if !ok {
+			destroyGenerator(gen)
+			return nil, fmt.Errorf("%w: generator does not support the health test",
+				ErrInvalidOption)
+		}
+		h.EnableHealthTest()
+	}
+
 	acc := &Accumulator{
-		gen: NewGenerator(newCipher),
+		gen:               gen,
+		onHealthFailure:   cfg.onHealthFailure,
+		pool:              make([]hash.Hash, cfg.numPools),
+		newHash:           cfg.newHash,
+		minPoolSize:       cfg.minPoolSize,
+		minReseedInterval: cfg.minReseedInterval,
+		maxSources:        cfg.maxSources,
 	}
 	for i := 0; i < len(acc.pool); i++ {
-		acc.pool[i] = sha256d.New()
+		acc.pool[i] = cfg.newHash()
+	}
+	if cfg.secureMemory {
+		poolSeedSize := len(acc.pool) * acc.pool[0].Size()
+		acc.mem = newSecureRegion(poolSeedSize + seedFileSize)
+		acc.poolSeed = acc.mem.alloc(poolSeedSize)
+		acc.fileSeed = acc.mem.alloc(seedFileSize)
 	}
 	acc.stopSources = make(chan bool)
+	acc.collectCtx, acc.stopCollectors = context.WithCancel(context.Background())
 
-	if seedFileName != "" {
-		seedFile, err := os.OpenFile(seedFileName,
+	if cfg.seedFileName != "" {
+		seedFile, err := os.OpenFile(cfg.seedFileName,
 			os.O_RDWR|os.O_CREATE|os.O_SYNC, os.FileMode(0600))
 		if err != nil {
+			acc.release()
 			return nil, err
 		}
 		acc.seedFile = seedFile
@@ -114,6 +206,7 @@ func NewAccumulator(newCipher NewCipher, seedFileName string) (*Accumulator, err
 		err = flock(acc.seedFile)
 		if err != nil {
 			acc.seedFile.Close()
+			acc.release()
 			return nil, err
 		}
 
@@ -123,13 +216,14 @@ func NewAccumulator(newCipher NewCipher, seedFileName string) (*Accumulator, err
 		err = acc.updateSeedFile()
 		if err != nil {
 			acc.seedFile.Close()
+			acc.release()
 			return nil, err
 		}
 
 		quit := make(chan bool)
 		acc.stopAutoSave = quit
 		go func() {
-			ticker := time.NewTicker(seedFileUpdateInterval)
+			ticker := time.NewTicker(cfg.seedFileUpdateInterval)
 			defer ticker.Stop()
 			for {
 				select {
@@ -149,39 +243,53 @@ func NewAccumulator(newCipher NewCipher, seedFileName string) (*Accumulator, err
 // function frees all entropy pools and transfers the remaining
 // entropy into the underlying generator so that it can go into the
 // seed file.
-func (acc *Accumulator) tearDownPools() {
-	data := make([]byte, 0, numPools*sha256d.Size)
+func (acc *Accumulator) tearDownPools() error {
+	acc.genMutex.Lock()
+	defer acc.genMutex.Unlock()
+
+	data := acc.seedBuffer(acc.poolSeed, len(acc.pool)*acc.pool[0].Size())
 
 	acc.poolMutex.Lock()
-	for i := 0; i < numPools; i++ {
+	for i := 0; i < len(acc.pool); i++ {
 		data = acc.pool[i].Sum(data)
 		acc.pool[i] = nil
 	}
+	acc.pool = nil       // reject events submitted after Close()
 	acc.poolZeroSize = 0 // prevent accidential last-minute reseeding
 	acc.poolMutex.Unlock()
 
-	acc.genMutex.Lock()
-	acc.gen.Reseed(data)
-	acc.genMutex.Unlock()
+	err := acc.gen.ReseedE(data)
+	wipe(data)
+	return err
 }
 
+// seedBuffer returns an empty slice with capacity n, using the locked
+// memory buf if it is large enough.  Callers must wipe the buffer
+// after use.
+func (acc *Accumulator) seedBuffer(buf []byte, n int) []byte {
+	if cap(buf) >= n {
+		return buf[:0]
+	}
+	return make([]byte, 0, n)
+}
+
+// tryReseeding returns a new seed for the generator, taken from the
+// entropy pools, if a reseed is due.  Otherwise nil is returned.  The
+// caller must hold genMutex and must wipe the seed after use.
 func (acc *Accumulator) tryReseeding() []byte {
 	now := time.Now()
 
 	acc.poolMutex.Lock()
 	defer acc.poolMutex.Unlock()
 
-	if acc.poolZeroSize >= minPoolSize && now.After(acc.nextReseed) {
-		acc.nextReseed = now.Add(minReseedInterval)
+	if acc.poolZeroSize >= acc.minPoolSize && now.After(acc.nextReseed) {
+		acc.nextReseed = now.Add(acc.minReseedInterval)
 		acc.poolZeroSize = 0
 		acc.reseedCount++
 
-		seed := make([]byte, 0, numPools*sha256d.Size)
-		for i := uint(0); i < numPools; i++ {
-			x := 1 << i
-			if acc.reseedCount%x != 0 {
-				break
-			}
+		k := reseedPools(acc.reseedCount, len(acc.pool))
+		seed := acc.seedBuffer(acc.poolSeed, k*acc.pool[0].Size())
+		for i := 0; i < k; i++ {
 			seed = acc.pool[i].Sum(seed)
 			acc.pool[i].Reset()
 		}
@@ -190,33 +298,80 @@ func (acc *Accumulator) tryReseeding() []byte {
 	return nil
 }
 
+// reseedPools returns the number of entropy pools which contribute to
+// reseed number reseedCount, when numPools pools are available.  Pool
+// i is used if 2^i divides the reseed count, so that the returned
+// value k means that pools 0, ..., k-1 are used.
+func reseedPools(reseedCount uint64, numPools int) int {
+	k := bits.TrailingZeros64(reseedCount) + 1
+	if k > numPools {
+		k = numPools
+	}
+	return k
+}
+
 // RandomData returns a slice of n random bytes.  The result can be
 // used as a replacement for a sequence of uniformly distributed and
 // independent bytes, and will be difficult to guess for an attacker.
 func (acc *Accumulator) RandomData(n uint) []byte {
-	seed := acc.tryReseeding()
+	res := make([]byte, n)
+	acc.FillBytes(res)
+	return res
+}
+
+// FillBytes fills dst with random bytes.  This is the same as
+// RandomData(), but the data is written directly into the caller's
+// buffer.  Apart from the memory needed to rekey the block cipher, and
+// occasional allocations when the generator is reseeded from the
+// entropy pools, FillBytes does not allocate memory.
+func (acc *Accumulator) FillBytes(dst []byte) {
 	acc.genMutex.Lock()
 	defer acc.genMutex.Unlock()
-	if seed != nil {
-		acc.gen.Reseed(seed)
+	acc.fillBytesUnlocked(dst)
+}
+
+func (acc *Accumulator) fillBytesUnlocked(dst []byte) {
+	err := acc.fillUnlocked(dst)
+	if err != nil {
+		panic(err)
 	}
-	return acc.gen.PseudoRandomData(n)
 }
 
-func (acc *Accumulator) randomDataUnlocked(n uint) []byte {
+// fillUnlocked fills dst with random bytes, reseeding the generator
+// first if required.  The caller must hold genMutex.
+func (acc *Accumulator) fillUnlocked(dst []byte) error {
 	seed := acc.tryReseeding()
 	if seed != nil {
-		acc.gen.Reseed(seed)
+		err := acc.gen.ReseedE(seed)
+		wipe(seed)
+		if err != nil {
+			return err
+		}
 	}
-	return acc.gen.PseudoRandomData(n)
+	err := acc.gen.Fill(dst)
+	if errors.Is(err, ErrHealthTest) && !acc.healthFailed {
+		acc.healthFailed = true
+		if acc.onHealthFailure != nil {
+			acc.onHealthFailure(err)
+		}
+	}
+	return err
 }
 
 // Read allows to extract randomness from the Accumulator using the
 // io.Reader interface.  Read fills the byte slice p with random
-// bytes.  The method always reads len(p) bytes and never returns an
-// error.
+// bytes.  The method always reads len(p) bytes and only returns an
+// error if the underlying generator fails, for example once the
+// continuous health test enabled by WithHealthTest() has detected a
+// repeated output block.  In this case, n is 0 and no random data is
+// returned.
 func (acc *Accumulator) Read(p []byte) (n int, err error) {
-	copy(p, acc.RandomData(uint(len(p))))
+	acc.genMutex.Lock()
+	defer acc.genMutex.Unlock()
+	err = acc.fillUnlocked(p)
+	if err != nil {
+		return 0, err
+	}
 	return len(p), nil
 }
 
@@ -225,32 +380,63 @@ func (acc *Accumulator) Read(p []byte) (n int, err error) {
 // Accumulator must not be used any more.
 func (acc *Accumulator) Close() error {
 	close(acc.stopSources)
+	acc.stopCollectors()
 	acc.sources.Wait()
 
-	acc.tearDownPools()
+	err := acc.tearDownPools()
 
-	var err error
 	if acc.seedFile != nil {
 		acc.stopAutoSave <- true
-		err = acc.writeSeedFile()
+		if err == nil {
+			err = acc.writeSeedFile()
+		}
 		acc.seedFile.Close()
 		acc.seedFile = nil
 	}
 
-	// Reset the underlying PRNG to ensure that (1) the Accumulator
+	// Destroy the underlying PRNG to ensure that (1) the Accumulator
 	// cannot be used any more after Close() has been called and (2)
 	// information about the key is not retained in memory
 	// indefinitely.
-	acc.gen.reset()
+	acc.release()
 
 	return err
 }
 
+// release frees the locked memory of the Accumulator and destroys the
+// underlying generator.  This is used by Close(), and to clean up when
+// NewAccumulatorWithOptions() fails after the generator has been
+// created.
+func (acc *Accumulator) release() {
+	if acc.mem != nil {
+		acc.mem.free()
+		acc.mem = nil
+		acc.poolSeed = nil
+		acc.fileSeed = nil
+	}
+	destroyGenerator(acc.gen)
+}
+
+// destroyGenerator wipes the key material of gen and frees its locked
+// memory, if any.  Generators without a Destroy() method are reset to
+// the unseeded state instead.
+func destroyGenerator(gen RandomGenerator) {
+	switch gen := gen.(type) {
+	case interface{ Destroy() }:
+		gen.Destroy()
+	case interface{ reset() }:
+		gen.reset()
+	}
+}
+
 // Int63 returns a positive random integer, uniformly distributed on
 // the range 0, 1, ..., 2^63-1.  This function is part of the
 // rand.Source interface.
 func (acc *Accumulator) Int63() int64 {
-	bytes := acc.RandomData(8)
+	acc.genMutex.Lock()
+	defer acc.genMutex.Unlock()
+	bytes := acc.intBuf[:]
+	acc.fillBytesUnlocked(bytes)
 	bytes[0] &= 0x7f
 	return bytesToInt64(bytes)
 }
@@ -259,7 +445,10 @@ func (acc *Accumulator) Int63() int64 {
 // the range 0, 1, ..., 2^64-1.  This function is part of the
 // rand.Source64 interface.
 func (acc *Accumulator) Uint64() uint64 {
-	bytes := acc.RandomData(8)
+	acc.genMutex.Lock()
+	defer acc.genMutex.Unlock()
+	bytes := acc.intBuf[:]
+	acc.fillBytesUnlocked(bytes)
 	return bytesToUint64(bytes)
 }
 
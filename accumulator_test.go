@@ -18,6 +18,7 @@ package fortuna
 
 import (
 	"bytes"
+	"crypto/aes"
 	"crypto/rand"
 	"io"
 	"io/ioutil"
@@ -34,7 +35,7 @@ func TestAccumulator(t *testing.T) {
 	// https://www.dlitz.net/software/pycrypto/ .
 
 	acc, _ := NewRNG("")
-	acc.gen.reset()
+	acc.gen.(*Generator).reset()
 
 	acc.addRandomEvent(0, 0, make([]byte, 32))
 	acc.addRandomEvent(0, 0, make([]byte, 32))
@@ -158,6 +159,22 @@ func BenchmarkAccumulatorRead16(b *testing.B) { accumulatorRead(b, 16) }
 func BenchmarkAccumulatorRead32(b *testing.B) { accumulatorRead(b, 32) }
 func BenchmarkAccumulatorRead1k(b *testing.B) { accumulatorRead(b, 1024) }
 
+func accumulatorFillBytes(b *testing.B, n int) {
+	acc, _ := NewRNG("")
+	buffer := make([]byte, n)
+
+	b.SetBytes(int64(n))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		acc.FillBytes(buffer)
+	}
+}
+
+func BenchmarkAccumulatorFillBytes16(b *testing.B) { accumulatorFillBytes(b, 16) }
+func BenchmarkAccumulatorFillBytes32(b *testing.B) { accumulatorFillBytes(b, 32) }
+func BenchmarkAccumulatorFillBytes1k(b *testing.B) { accumulatorFillBytes(b, 1024) }
+
 func cryptoRandRead(b *testing.B, n int) {
 	buffer := make([]byte, n)
 
@@ -174,6 +191,27 @@ func BenchmarkCryptoRandRead16(b *testing.B) { cryptoRandRead(b, 16) }
 func BenchmarkCryptoRandRead32(b *testing.B) { cryptoRandRead(b, 32) }
 func BenchmarkCryptoRandRead1k(b *testing.B) { cryptoRandRead(b, 1024) }
 
+func TestAccumulatorFillBytes(t *testing.T) {
+	acc, _ := NewRNG("")
+	defer acc.Close()
+
+	key := make([]byte, keySize)
+	cipherAllocs := testing.AllocsPerRun(100, func() {
+		aes.NewCipher(key)
+	})
+
+	buf := make([]byte, 100)
+	allocs := testing.AllocsPerRun(100, func() {
+		acc.FillBytes(buf)
+		acc.Read(buf)
+		acc.Int63()
+		acc.Uint64()
+	})
+	if allocs > 4*cipherAllocs {
+		t.Errorf("%g allocations, expected %g", allocs, 4*cipherAllocs)
+	}
+}
+
 func TestRandInt63(t *testing.T) {
 	acc, _ := NewRNG("")
 	for i := 0; i < 100; i++ {
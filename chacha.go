@@ -0,0 +1,254 @@
+// chacha.go - a Fortuna-style generator based on ChaCha20
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package fortuna
+
+import (
+	"encoding/binary"
+	"hash"
+	"math/bits"
+
+	"github.com/seehuhn/sha256d"
+)
+
+const (
+	// chachaBlockSize is the size of one ChaCha20 key stream block in
+	// bytes.
+	chachaBlockSize = 64
+
+	// chachaKeySize is the size of a ChaCha20 key in bytes.
+	chachaKeySize = 32
+)
+
+// chachaBlock computes one block of the ChaCha20 key stream, as
+// described in RFC 8439, section 2.3.  The 128 bits following the
+// key in the ChaCha20 state are given by counter (the block counter
+// and the first word of the nonce in RFC 8439) and by nonce (the
+// remaining two words of the nonce).
+func chachaBlock(out *[chachaBlockSize]byte, key *[8]uint32, counter uint64, nonce [2]uint32) {
+	var s [16]uint32
+	s[0] = 0x61707865
+	s[1] = 0x3320646e
+	s[2] = 0x79622d32
+	s[3] = 0x6b206574
+	copy(s[4:12], key[:])
+	s[12] = uint32(counter)
+	s[13] = uint32(counter >> 32)
+	s[14] = nonce[0]
+	s[15] = nonce[1]
+
+	x := s
+	for i := 0; i < 10; i++ {
+		// column rounds
+		x[0], x[4], x[8], x[12] = quarterRound(x[0], x[4], x[8], x[12])
+		x[1], x[5], x[9], x[13] = quarterRound(x[1], x[5], x[9], x[13])
+		x[2], x[6], x[10], x[14] = quarterRound(x[2], x[6], x[10], x[14])
+		x[3], x[7], x[11], x[15] = quarterRound(x[3], x[7], x[11], x[15])
+		// diagonal rounds
+		x[0], x[5], x[10], x[15] = quarterRound(x[0], x[5], x[10], x[15])
+		x[1], x[6], x[11], x[12] = quarterRound(x[1], x[6], x[11], x[12])
+		x[2], x[7], x[8], x[13] = quarterRound(x[2], x[7], x[8], x[13])
+		x[3], x[4], x[9], x[14] = quarterRound(x[3], x[4], x[9], x[14])
+	}
+
+	for i := range x {
+		binary.LittleEndian.PutUint32(out[4*i:], x[i]+s[i])
+	}
+}
+
+func quarterRound(a, b, c, d uint32) (uint32, uint32, uint32, uint32) {
+	a += b
+	d = bits.RotateLeft32(d^a, 16)
+	c += d
+	b = bits.RotateLeft32(b^c, 12)
+	a += b
+	d = bits.RotateLeft32(d^a, 8)
+	c += d
+	b = bits.RotateLeft32(b^c, 7)
+	return a, b, c, d
+}
+
+// ChaChaGenerator is a variant of the Fortuna Generator which uses the
+// ChaCha20 stream cipher instead of a block cipher in counter mode.
+// ChaCha20 is fast and runs in constant time in pure Go, which makes
+// this generator a good choice for machines without hardware support
+// for AES.
+//
+// Apart from the output function, ChaChaGenerator works like
+// Generator: reseeding hashes the old key together with the seed using
+// SHA-256d (or the hash set using WithHash(), when the generator is
+// used by an Accumulator), the key stream block counter is never
+// reset, and the key is replaced with fresh output at the end of every
+// request.  At most 2^16 key stream blocks (4 MiB) are generated using
+// the same key.
+//
+// A ChaChaGenerator is not safe for use with concurrent access.
+type ChaChaGenerator struct {
+	key      [chachaKeySize]byte
+	keyWords [8]uint32
+	counter  uint64
+	newHash  func() hash.Hash
+
+	// set by Destroy()
+	destroyed bool
+
+	// scratch space, to avoid allocations when generating output
+	block [chachaBlockSize]byte
+}
+
+// NewChaChaGenerator allocates a new ChaChaGenerator.  The initial
+// seed is chosen in the same way as for NewGeneratorE().  If no initial
+// seed can be obtained, ErrNoEntropy is returned.  If the power-on self
+// test fails, an error wrapping ErrSelfTest is returned.
+func NewChaChaGenerator() (*ChaChaGenerator, error) {
+	return newChaChaGenerator(sha256d.New)
+}
+
+// newChaChaGenerator allocates a new, seeded ChaChaGenerator which uses
+// newHash for reseeding.  The hash must produce at least 32 bytes of
+// output.
+func newChaChaGenerator(newHash func() hash.Hash) (*ChaChaGenerator, error) {
+	err := powerOnSelfTest()
+	if err != nil {
+		return nil, err
+	}
+
+	gen := &ChaChaGenerator{
+		newHash: newHash,
+	}
+	buf, err := initialSeedData()
+	if err != nil {
+		return nil, err
+	}
+	gen.Reseed(buf)
+	wipe(buf)
+	return gen, nil
+}
+
+func (gen *ChaChaGenerator) setKey(key []byte) {
+	copy(gen.key[:], key)
+	for i := range gen.keyWords {
+		gen.keyWords[i] = binary.LittleEndian.Uint32(gen.key[4*i:])
+	}
+}
+
+// reset reverts the generator to the unseeded state and wipes the key.
+func (gen *ChaChaGenerator) reset() {
+	wipe(gen.key[:])
+	for i := range gen.keyWords {
+		gen.keyWords[i] = 0
+	}
+	gen.counter = 0
+}
+
+// Destroy wipes the key and all scratch space of the generator.
+// Afterwards the generator is permanently unusable: Fill() and
+// ReseedE() return ErrDestroyed, and all other methods panic.
+func (gen *ChaChaGenerator) Destroy() {
+	gen.reset()
+	wipe(gen.block[:])
+	gen.destroyed = true
+}
+
+// Reseed uses the current generator state and the given seed value to
+// update the generator state, in the same way as Generator.Reseed().
+// Reseed panics if the generator has been destroyed.
+func (gen *ChaChaGenerator) Reseed(seed []byte) {
+	if gen.destroyed {
+		panic(ErrDestroyed)
+	}
+	newHash := gen.newHash
+	if newHash == nil {
+		newHash = sha256d.New
+	}
+	hash := newHash()
+	hash.Write(gen.key[:])
+	hash.Write(seed)
+	key := hash.Sum(nil)
+	gen.setKey(key[:chachaKeySize])
+	wipe(key)
+	gen.counter++
+}
+
+// ReseedE is the same as Reseed(), but returns ErrDestroyed instead of
+// panicking if the generator has been destroyed.  The method is
+// provided so that a ChaChaGenerator can be used as the generator of
+// an Accumulator, see WithChaCha().
+func (gen *ChaChaGenerator) ReseedE(seed []byte) error {
+	if gen.destroyed {
+		return ErrDestroyed
+	}
+	gen.Reseed(seed)
+	return nil
+}
+
+// generateBlocks fills dst with key stream.  For every (full or
+// partial) block of dst, the counter is incremented once.
+func (gen *ChaChaGenerator) generateBlocks(dst []byte) {
+	for len(dst) > 0 {
+		chachaBlock(&gen.block, &gen.keyWords, gen.counter, [2]uint32{})
+		gen.counter++
+		n := copy(dst, gen.block[:])
+		dst = dst[n:]
+	}
+	wipe(gen.block[:])
+}
+
+// Fill fills dst with pseudo-random bytes and then replaces the key.
+// If the generator has not been seeded, ErrNotSeeded is returned.  If
+// the generator has been destroyed, ErrDestroyed is returned.  Fill
+// does not allocate memory.
+func (gen *ChaChaGenerator) Fill(dst []byte) error {
+	if gen.destroyed {
+		return ErrDestroyed
+	}
+	if gen.counter == 0 {
+		return ErrNotSeeded
+	}
+
+	chunkSize := maxBlocks * chachaBlockSize
+	for len(dst) > 0 {
+		n := len(dst)
+		if n > chunkSize {
+			n = chunkSize
+		}
+		gen.generateBlocks(dst[:n])
+		dst = dst[n:]
+
+		var newKey [chachaKeySize]byte
+		gen.generateBlocks(newKey[:])
+		gen.setKey(newKey[:])
+		wipe(newKey[:])
+	}
+	return nil
+}
+
+// FillBytes is like Fill(), but panics if the generator has not been
+// seeded.
+func (gen *ChaChaGenerator) FillBytes(dst []byte) {
+	err := gen.Fill(dst)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// PseudoRandomData returns a slice of n pseudo-random bytes.  The
+// method panics if the generator has not been seeded.
+func (gen *ChaChaGenerator) PseudoRandomData(n uint) []byte {
+	res := make([]byte, n)
+	gen.FillBytes(res)
+	return res
+}
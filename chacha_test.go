@@ -0,0 +1,196 @@
+// chacha_test.go - unit tests for chacha.go
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package fortuna
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// chachaKeyStream computes the ChaCha20 key stream with the parameter
+// layout of RFC 8439: a 32 bit block counter and a 96 bit nonce.
+func chachaKeyStream(out, key []byte, counter uint32, nonce []byte) {
+	var keyWords [8]uint32
+	for i := range keyWords {
+		keyWords[i] = binary.LittleEndian.Uint32(key[4*i:])
+	}
+	ctr := uint64(counter) | uint64(binary.LittleEndian.Uint32(nonce[0:]))<<32
+	n := [2]uint32{
+		binary.LittleEndian.Uint32(nonce[4:]),
+		binary.LittleEndian.Uint32(nonce[8:]),
+	}
+	var block [chachaBlockSize]byte
+	for len(out) > 0 {
+		chachaBlock(&block, &keyWords, ctr, n)
+		ctr++
+		k := copy(out, block[:])
+		out = out[k:]
+	}
+}
+
+func TestChaChaBlock(t *testing.T) {
+	key := make([]byte, 32)
+	for i := range key {
+		key[i] = byte(i)
+	}
+	oneKey := make([]byte, 32)
+	oneKey[31] = 1
+
+	cases := []struct {
+		key     []byte
+		counter uint32
+		nonce   string
+		out     string
+	}{
+		{ // RFC 8439, section 2.3.2
+			key, 1, "000000090000004a00000000",
+			"10f1e7e4d13b5915500fdd1fa32071c4c7d1f4c733c068030422aa9ac3d46c4e" +
+				"d2826446079faa0914c2d705d98b02a2b5129cd1de164eb9cbd083e8a2503c4e",
+		},
+		{ // RFC 8439, appendix A.1, test vector #1
+			make([]byte, 32), 0, "000000000000000000000000",
+			"76b8e0ada0f13d90405d6ae55386bd28bdd219b8a08ded1aa836efcc8b770dc7" +
+				"da41597c5157488d7724e03fb8d84a376a43b8f41518a11cc387b669b2ee6586",
+		},
+		{ // RFC 8439, appendix A.1, test vector #2
+			make([]byte, 32), 1, "000000000000000000000000",
+			"9f07e7be5551387a98ba977c732d080dcb0f29a048e3656912c6533e32ee7aed" +
+				"29b721769ce64e43d57133b074d839d531ed1f28510afb45ace10a1f4b794d6f",
+		},
+		{ // RFC 8439, appendix A.1, test vector #3
+			oneKey, 1, "000000000000000000000000",
+			"3aeb5224ecf849929b9d828db1ced4dd832025e8018b8160b82284f3c949aa5a" +
+				"8eca00bbb4a73bdad192b5c42f73f2fd4e273644c8b36125a64addeb006c13a0",
+		},
+	}
+	for i, c := range cases {
+		out := make([]byte, chachaBlockSize)
+		chachaKeyStream(out, c.key, c.counter, unhex(c.nonce))
+		if !bytes.Equal(out, unhex(c.out)) {
+			t.Errorf("%d: wrong key stream", i)
+		}
+	}
+}
+
+func TestChaChaEncryption(t *testing.T) {
+	// RFC 8439, section 2.4.2
+	key := make([]byte, 32)
+	for i := range key {
+		key[i] = byte(i)
+	}
+	plainText := []byte("Ladies and Gentlemen of the class of '99: If I could " +
+		"offer you only one tip for the future, sunscreen would be it.")
+	cipherText := unhex("6e2e359a2568f98041ba0728dd0d6981" +
+		"e97e7aec1d4360c20a27afccfd9fae0b" +
+		"f91b65c5524733ab8f593dabcd62b357" +
+		"1639d624e65152ab8f530c359f0861d8" +
+		"07ca0dbf500d6a6156a38e088a22b65e" +
+		"52bc514d16ccf806818ce91ab7793736" +
+		"5af90bbf74a35be6b40b8eedf2785e42" +
+		"874d")
+
+	out := make([]byte, len(plainText))
+	chachaKeyStream(out, key, 1, unhex("000000000000004a00000000"))
+	for i, x := range plainText {
+		out[i] ^= x
+	}
+	if !bytes.Equal(out, cipherText) {
+		t.Error("wrong cipher text")
+	}
+}
+
+func TestChaChaGenerator(t *testing.T) {
+	gen := &ChaChaGenerator{}
+	if err := gen.Fill(make([]byte, 1)); err != ErrNotSeeded {
+		t.Errorf("unseeded generator not detected: %v", err)
+	}
+
+	// The generator output is the ChaCha20 key stream for the key
+	// SHA-256d(seed), starting at block counter 1.
+	gen.Reseed([]byte{1, 2, 3, 4})
+	key := append([]byte{}, gen.key[:]...)
+	out := gen.PseudoRandomData(100)
+	expected := make([]byte, 100)
+	chachaKeyStream(expected, key, 1, make([]byte, 12))
+	if !bytes.Equal(out, expected) {
+		t.Error("wrong generator output")
+	}
+
+	// the key is replaced after every request
+	if bytes.Equal(gen.key[:], key) {
+		t.Error("key not replaced")
+	}
+	if gen.counter != 1+2+1 {
+		t.Errorf("wrong counter %d", gen.counter)
+	}
+
+	// large requests are split into chunks of 2^16 blocks
+	gen.reset()
+	gen.Reseed([]byte{5})
+	x := gen.PseudoRandomData(2 * maxBlocks * chachaBlockSize)
+	gen.reset()
+	gen.Reseed([]byte{5})
+	y := gen.PseudoRandomData(maxBlocks * chachaBlockSize)
+	y = append(y, gen.PseudoRandomData(maxBlocks*chachaBlockSize)...)
+	if !bytes.Equal(x, y) {
+		t.Error("wrong rekeying interval")
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		gen.FillBytes(out)
+	})
+	if allocs != 0 {
+		t.Errorf("FillBytes made %g allocations", allocs)
+	}
+}
+
+func TestAccumulatorChaCha(t *testing.T) {
+	acc, err := NewAccumulatorWithOptions(WithChaCha())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := acc.gen.(*ChaChaGenerator); !ok {
+		t.Fatal("wrong generator type")
+	}
+	x := acc.RandomData(100)
+	y := acc.RandomData(100)
+	if bytes.Equal(x, y) {
+		t.Error("repeated output")
+	}
+
+	acc.Close()
+	gen := acc.gen.(*ChaChaGenerator)
+	if gen.counter != 0 || !isZero(gen.key[:]) {
+		t.Error("generator not wiped by Close")
+	}
+}
+
+func BenchmarkChaChaGenerator1k(b *testing.B) {
+	gen, _ := NewChaChaGenerator()
+	buffer := make([]byte, 1024)
+
+	b.SetBytes(int64(len(buffer)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		gen.FillBytes(buffer)
+	}
+}
+
+// compile-time test: ChaChaGenerator implements the RandomGenerator
+// interface
+var _ RandomGenerator = &ChaChaGenerator{}
@@ -0,0 +1,136 @@
+// collector.go - entropy collectors scheduled by the Accumulator
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package fortuna
+
+import (
+	"context"
+	"fmt"
+	mrand "math/rand"
+	"time"
+)
+
+const (
+	// minCollectorInterval is the shortest time between two calls to
+	// the Collect() method of an EntropyCollector.
+	minCollectorInterval = 10 * time.Millisecond
+
+	// maxCollectorBackoff is the longest time a failing collector is
+	// paused, unless its own interval is longer.
+	maxCollectorBackoff = 10 * time.Minute
+)
+
+// EntropyCollector is the interface for entropy sources which are
+// polled by an Accumulator, see Accumulator.AddCollector().
+//
+// If a collector also has a method Name() string, the name is used
+// for the Source which passes the data to the entropy pools.
+type EntropyCollector interface {
+	// Collect returns data which cannot be (completely) known to an
+	// attacker.  Data longer than 32 bytes is hashed before it is
+	// added to the entropy pools.  The Accumulator wipes the returned
+	// slice after use.  The context is cancelled when the
+	// Accumulator is closed.
+	Collect(ctx context.Context) ([]byte, error)
+
+	// Interval returns the suggested time between two calls to
+	// Collect().
+	Interval() time.Duration
+}
+
+// AddCollector registers c with the Accumulator.  The Accumulator
+// calls c.Collect() from a separate goroutine, approximately every
+// c.Interval(), until the Accumulator is closed.  Each interval is
+// varied randomly by up to 25% in either direction, so that different
+// collectors do not run in lock-step.  Intervals shorter than 10ms are
+// rounded up.  If Collect() returns an error, the data is discarded and
+// the interval is doubled after each consecutive failure, up to 10
+// minutes (or c.Interval(), if this is longer).  The interval returns
+// to normal after the next successful call.
+//
+// Each collector uses one Source, see NewSource().  If the maximal
+// number of sources is already in use, ErrTooManySources is returned.
+// If the Accumulator has been closed, ErrClosed is returned.
+func (acc *Accumulator) AddCollector(c EntropyCollector) error {
+	if acc.collectCtx.Err() != nil {
+		return ErrClosed
+	}
+
+	name := fmt.Sprintf("%T", c)
+	if n, ok := c.(interface{ Name() string }); ok {
+		name = n.Name()
+	}
+	src, err := acc.NewSource(name)
+	if err != nil {
+		return err
+	}
+
+	acc.sources.Add(1)
+	go func() {
+		defer acc.sources.Done()
+		defer src.Close()
+
+		failures := uint(0)
+		for {
+			timer := time.NewTimer(collectorDelay(c.Interval(), failures))
+			select {
+			case <-timer.C:
+			case <-acc.collectCtx.Done():
+				timer.Stop()
+				return
+			}
+
+			data, err := c.Collect(acc.collectCtx)
+			if acc.collectCtx.Err() != nil {
+				wipe(data)
+				return
+			}
+			if err != nil {
+				wipe(data)
+				failures++
+				continue
+			}
+			failures = 0
+			src.Add(data)
+			wipe(data)
+		}
+	}()
+	return nil
+}
+
+// collectorDelay returns the time to wait before the next call to
+// Collect(), for a collector with the given interval after the given
+// number of consecutive failures.
+func collectorDelay(interval time.Duration, failures uint) time.Duration {
+	if interval < minCollectorInterval {
+		interval = minCollectorInterval
+	}
+
+	d := interval
+	limit := maxCollectorBackoff
+	if limit < interval {
+		limit = interval
+	}
+	for i := uint(0); i < failures && d < limit; i++ {
+		d *= 2
+	}
+	if d > limit {
+		d = limit
+	}
+
+	// random jitter in the range [-d/4, d/4)
+	return d - d/4 + time.Duration(mrand.Int63n(int64(d/2)))
+}
@@ -0,0 +1,144 @@
+// collector_test.go - unit tests for collector.go
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package fortuna
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type countingCollector struct {
+	calls int32
+	fail  bool
+	block bool
+}
+
+func (c *countingCollector) Collect(ctx context.Context) ([]byte, error) {
+	atomic.AddInt32(&c.calls, 1)
+	if c.block {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	if c.fail {
+		return nil, errors.New("collector failed")
+	}
+	return []byte{1, 2, 3, 4}, nil
+}
+
+func (c *countingCollector) Interval() time.Duration {
+	return time.Millisecond
+}
+
+func TestCollector(t *testing.T) {
+	acc, err := NewAccumulatorWithOptions()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	good := &countingCollector{}
+	bad := &countingCollector{fail: true}
+	stuck := &countingCollector{block: true}
+	for _, c := range []*countingCollector{good, bad, stuck} {
+		err = acc.AddCollector(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	time.Sleep(300 * time.Millisecond)
+
+	// The blocked collector must be cancelled by Close().
+	done := make(chan error)
+	go func() {
+		done <- acc.Close()
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Error(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close() did not stop the collectors")
+	}
+
+	nGood := atomic.LoadInt32(&good.calls)
+	nBad := atomic.LoadInt32(&bad.calls)
+	if nGood < 5 {
+		t.Errorf("collector called only %d times", nGood)
+	}
+	// with exponential backoff, starting at 10ms, the failing
+	// collector can run at most 6 times in 300ms
+	if nBad < 1 || nBad > 6 {
+		t.Errorf("failing collector called %d times", nBad)
+	}
+	if atomic.LoadInt32(&stuck.calls) != 1 {
+		t.Errorf("blocked collector called %d times", stuck.calls)
+	}
+	if acc.activeSources != 0 {
+		t.Errorf("%d sources still active", acc.activeSources)
+	}
+
+	err = acc.AddCollector(good)
+	if !errors.Is(err, ErrClosed) {
+		t.Errorf("AddCollector after Close: wrong error %v", err)
+	}
+}
+
+func TestCollectorLimit(t *testing.T) {
+	acc, err := NewAccumulatorWithOptions(WithMaxSources(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer acc.Close()
+
+	err = acc.AddCollector(&countingCollector{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = acc.AddCollector(&countingCollector{})
+	if !errors.Is(err, ErrTooManySources) {
+		t.Errorf("source limit not enforced: %v", err)
+	}
+}
+
+func TestCollectorDelay(t *testing.T) {
+	cases := []struct {
+		interval time.Duration
+		failures uint
+		expected time.Duration
+	}{
+		{time.Second, 0, time.Second},
+		{time.Second, 3, 8 * time.Second},
+		{time.Second, 100, maxCollectorBackoff},
+		{time.Hour, 5, time.Hour},
+		{0, 0, minCollectorInterval},
+		{time.Millisecond, 1, 2 * minCollectorInterval},
+	}
+	for _, c := range cases {
+		for i := 0; i < 100; i++ {
+			d := collectorDelay(c.interval, c.failures)
+			if d < c.expected*3/4 || d >= c.expected*5/4 {
+				t.Errorf("%v/%d: delay %v not within 25%% of %v",
+					c.interval, c.failures, d, c.expected)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,216 @@
+// collectors.go - ready-made entropy collectors for Linux systems
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Package collectors provides entropy collectors which periodically
+// feed system statistics into the entropy pools of a
+// fortuna.Accumulator.  The collectors read files in the /proc
+// filesystem of Linux systems, like /proc/interrupts or /proc/net/dev,
+// whose contents change constantly and are difficult to predict
+// exactly for an attacker, and bytes from the system random number
+// generator.
+//
+// The simplest way to use the package is to call AddAll() after the
+// Accumulator has been created:
+//
+//     rng, err := fortuna.NewRNG(seedFileName)
+//     if err != nil {
+//         panic("cannot initialise the RNG: " + err.Error())
+//     }
+//     defer rng.Close()
+//     err = collectors.AddAll(rng, collectors.DefaultRoot)
+//
+// Individual collectors can be added using Accumulator.AddCollector().
+// On machines with little disk or network activity, the Jitter
+// collector, which measures timing variations of the CPU, can be added
+// in this way; it is not included in All() since it keeps the CPU busy
+// while sampling.
+//
+// The root directory of the proc filesystem can be changed, so that
+// the collectors can be tested against fixture files.
+package collectors
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	"github.com/seehuhn/fortuna"
+)
+
+// DefaultRoot is the location of the proc filesystem on Linux systems.
+const DefaultRoot = "/proc"
+
+// ErrNoData is returned by ProcFile.Collect() if the file is empty.
+var ErrNoData = errors.New("no data")
+
+// ProcFile is an entropy collector which returns the contents of one
+// file in the proc filesystem.  The data is hashed by the Accumulator
+// before it is added to the entropy pools.
+type ProcFile struct {
+	// Root is the root directory of the proc filesystem.
+	Root string
+
+	// Path is the location of the file, relative to Root.
+	Path string
+
+	// Every is the suggested time between two reads of the file.
+	Every time.Duration
+}
+
+// Collect reads the file.  If the file cannot be read, the error is
+// returned.  If the file is empty, ErrNoData is returned.
+func (c *ProcFile) Collect(ctx context.Context) ([]byte, error) {
+	err := ctx.Err()
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadFile(filepath.Join(c.Root, c.Path))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, ErrNoData
+	}
+	return data, nil
+}
+
+// Interval returns the suggested time between two calls to Collect().
+func (c *ProcFile) Interval() time.Duration {
+	return c.Every
+}
+
+// Name returns the path of the file, for diagnostics.
+func (c *ProcFile) Name() string {
+	return filepath.Join(c.Root, c.Path)
+}
+
+// Interrupts returns a collector for the per-CPU interrupt counters in
+// /proc/interrupts.  The file is read every 2 seconds.
+func Interrupts(root string) *ProcFile {
+	return &ProcFile{Root: root, Path: "interrupts", Every: 2 * time.Second}
+}
+
+// DiskStats returns a collector for the I/O statistics of the block
+// devices in /proc/diskstats.  The file is read every 5 seconds.
+func DiskStats(root string) *ProcFile {
+	return &ProcFile{Root: root, Path: "diskstats", Every: 5 * time.Second}
+}
+
+// NetDev returns a collector for the network interface statistics in
+// /proc/net/dev.  The file is read every 2 seconds.
+func NetDev(root string) *ProcFile {
+	return &ProcFile{Root: root, Path: filepath.Join("net", "dev"),
+		Every: 2 * time.Second}
+}
+
+// Stat returns a collector for the kernel statistics in /proc/stat,
+// which include CPU times, context switches and interrupt counts.
+// The file is read every second.
+func Stat(root string) *ProcFile {
+	return &ProcFile{Root: root, Path: "stat", Every: time.Second}
+}
+
+// LoadAvg returns a collector for the load averages and the last
+// process ID in /proc/loadavg.  The file is read every 10 seconds.
+func LoadAvg(root string) *ProcFile {
+	return &ProcFile{Root: root, Path: "loadavg", Every: 10 * time.Second}
+}
+
+// SystemRandom is an entropy collector which reads bytes from the
+// random number generator of the operating system.  On Linux, this
+// uses the getrandom system call.
+type SystemRandom struct {
+	// Reader is the source of the random bytes.  If Reader is nil,
+	// crypto/rand.Reader is used.
+	Reader io.Reader
+
+	// Size is the number of bytes read by each call to Collect().
+	Size int
+
+	// Every is the suggested time between two calls to Collect().
+	Every time.Duration
+}
+
+// GetRandom returns a collector which reads 32 bytes from the system
+// random number generator once a minute.
+func GetRandom() *SystemRandom {
+	return &SystemRandom{Size: 32, Every: time.Minute}
+}
+
+// Collect reads c.Size random bytes.
+func (c *SystemRandom) Collect(ctx context.Context) ([]byte, error) {
+	err := ctx.Err()
+	if err != nil {
+		return nil, err
+	}
+	r := c.Reader
+	if r == nil {
+		r = rand.Reader
+	}
+	buf := make([]byte, c.Size)
+	_, err = io.ReadFull(r, buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// Interval returns the suggested time between two calls to Collect().
+func (c *SystemRandom) Interval() time.Duration {
+	return c.Every
+}
+
+// Name returns "getrandom", for diagnostics.
+func (c *SystemRandom) Name() string {
+	return "getrandom"
+}
+
+// All returns all collectors provided by this package, using root as
+// the root directory of the proc filesystem.
+func All(root string) []fortuna.EntropyCollector {
+	return []fortuna.EntropyCollector{
+		Interrupts(root),
+		DiskStats(root),
+		NetDev(root),
+		Stat(root),
+		LoadAvg(root),
+		GetRandom(),
+	}
+}
+
+// AddAll adds all collectors provided by this package to acc, using
+// root as the root directory of the proc filesystem.  Collectors for
+// files which do not exist are skipped.  If a collector cannot be
+// added, the error from Accumulator.AddCollector() is returned.
+func AddAll(acc *fortuna.Accumulator, root string) error {
+	for _, c := range All(root) {
+		if pf, ok := c.(*ProcFile); ok {
+			_, err := ioutil.ReadFile(pf.Name())
+			if err != nil {
+				continue
+			}
+		}
+		err := acc.AddCollector(c)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+// collectors_test.go - unit tests for collectors.go
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package collectors
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/seehuhn/fortuna"
+)
+
+// testRoot is a fake proc filesystem with fixture files.
+var testRoot = filepath.Join("testdata", "proc")
+
+func TestProcFiles(t *testing.T) {
+	ctx := context.Background()
+	for _, c := range []*ProcFile{
+		Interrupts(testRoot),
+		DiskStats(testRoot),
+		NetDev(testRoot),
+		Stat(testRoot),
+		LoadAvg(testRoot),
+	} {
+		expected, err := ioutil.ReadFile(c.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := c.Collect(ctx)
+		if err != nil {
+			t.Errorf("%s: %v", c.Name(), err)
+			continue
+		}
+		if !bytes.Equal(data, expected) {
+			t.Errorf("%s: wrong data", c.Name())
+		}
+		if c.Interval() <= 0 {
+			t.Errorf("%s: invalid interval %v", c.Name(), c.Interval())
+		}
+	}
+}
+
+func TestProcFileErrors(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	c := LoadAvg(tempDir)
+	_, err = c.Collect(context.Background())
+	if !os.IsNotExist(err) {
+		t.Errorf("missing file: wrong error %v", err)
+	}
+
+	err = ioutil.WriteFile(c.Name(), nil, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.Collect(context.Background())
+	if err != ErrNoData {
+		t.Errorf("empty file: wrong error %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = Stat(testRoot).Collect(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled context: wrong error %v", err)
+	}
+}
+
+func TestGetRandom(t *testing.T) {
+	c := GetRandom()
+	data, err := c.Collect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 32 {
+		t.Errorf("wrong length %d", len(data))
+	}
+
+	c.Reader = bytes.NewReader([]byte{1, 2, 3})
+	c.Size = 3
+	data, err = c.Collect(context.Background())
+	if err != nil || !bytes.Equal(data, []byte{1, 2, 3}) {
+		t.Errorf("wrong result %v, %v", data, err)
+	}
+	_, err = c.Collect(context.Background())
+	if err == nil {
+		t.Error("read error not reported")
+	}
+}
+
+func TestAddAll(t *testing.T) {
+	acc, err := fortuna.NewAccumulatorWithOptions(fortuna.WithMaxSources(6))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = AddAll(acc, testRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// all six sources are in use now
+	_, err = acc.NewSource("extra")
+	if err != fortuna.ErrTooManySources {
+		t.Errorf("wrong number of collectors added: %v", err)
+	}
+	err = acc.Close()
+	if err != nil {
+		t.Error(err)
+	}
+
+	// collectors for missing files are skipped
+	acc, err = fortuna.NewAccumulatorWithOptions(fortuna.WithMaxSources(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer acc.Close()
+	err = AddAll(acc, filepath.Join("testdata", "missing"))
+	if err != nil {
+		t.Error(err)
+	}
+}
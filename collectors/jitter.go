@@ -0,0 +1,307 @@
+// jitter.go - an entropy collector based on CPU timing jitter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package collectors
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// jitterMemSize is the size of the memory area accessed while
+	// timing, chosen to exceed the L1 cache of typical CPUs.
+	jitterMemSize = 64 << 10
+
+	// jitterStride is the step between memory accesses.  This is a
+	// prime larger than a cache line, so that consecutive accesses
+	// touch different cache lines.
+	jitterStride = 67
+
+	// jitterMemSteps is the number of memory accesses per sample.
+	jitterMemSteps = 256
+
+	// jitterHashRounds is the number of SHA-256 invocations per sample.
+	jitterHashRounds = 2
+
+	// rctCutoff is the cutoff of the repetition count test from NIST
+	// SP 800-90B, section 4.4.1, for an assumed min-entropy of one bit
+	// per sample and a false positive probability of 2^-30.
+	rctCutoff = 31
+
+	// aptWindow and aptCutoff are the window size and the cutoff of the
+	// adaptive proportion test from NIST SP 800-90B, section 4.4.2,
+	// for an assumed min-entropy of one bit per sample.
+	aptWindow = 512
+	aptCutoff = 410
+
+	// maxBitsPerCollect bounds the entropy, in bits, which one call to
+	// Collect() is assumed to contribute to the entropy pools:
+	// Source.Add() reduces the data to a single hash value of at least
+	// 32 bytes.
+	maxBitsPerCollect = 256
+)
+
+// Errors returned by Jitter.Collect().
+var (
+	// ErrJitterStuck indicates that most timing samples were rejected by
+	// the stuck test, usually because the clock is too coarse.
+	ErrJitterStuck = errors.New("too many stuck timing samples")
+
+	// ErrJitterHealth indicates that the repetition count test or the
+	// adaptive proportion test has failed.
+	ErrJitterHealth = errors.New("jitter health test failed")
+)
+
+// Jitter is an entropy collector which measures the variation in the
+// time a CPU needs to execute a fixed sequence of memory accesses and
+// hash computations, in the style of the jitterentropy library.  The
+// variations are caused by caches, pipelines, interrupts and frequency
+// scaling, so that the collector works even on machines without disk
+// or network activity.  The timings are taken using the monotonic
+// clock of the time package.
+//
+// Every sample is checked by the stuck test of jitterentropy: a sample
+// is discarded if the measured time, or its first or second difference
+// to the preceding samples, is zero.  All samples are also subjected
+// to the repetition count test and the adaptive proportion test of
+// NIST SP 800-90B.  If one of these tests fails, Collect() returns an
+// error and the data is discarded.
+//
+// The min-entropy of the samples is estimated using the most common
+// value estimate of NIST SP 800-90B, section 6.3.1.  The estimate can
+// be obtained using Stats(), so that operators can judge whether the
+// collector produces useful entropy on their hardware.
+//
+// It is safe to call the methods of a Jitter concurrently.
+type Jitter struct {
+	// Samples is the number of timing samples taken by each call to
+	// Collect().
+	Samples int
+
+	// Every is the suggested time between two calls to Collect().
+	Every time.Duration
+
+	mutex   sync.Mutex
+	mem     []byte
+	pos     int
+	scratch [sha256.Size]byte
+	stats   JitterStats
+}
+
+// JitterStats describes the operation of a Jitter collector.
+type JitterStats struct {
+	// Samples is the total number of timing samples taken.
+	Samples int
+
+	// Stuck is the number of samples rejected by the stuck test.
+	Stuck int
+
+	// Failures is the number of calls to Collect() which failed
+	// because of a health test.
+	Failures int
+
+	// MinEntropy is the estimated min-entropy, in bits, of one
+	// accepted sample, as measured during the last successful call to
+	// Collect().
+	MinEntropy float64
+
+	// BitsPerCollect is the estimated min-entropy, in bits, which the
+	// last successful call to Collect() contributed to the entropy
+	// pools.  This is the min-entropy of all accepted samples, but at
+	// most 256 bits, since the Accumulator hashes the returned data
+	// before it reaches a pool.  If the value stays well below 256,
+	// the number of samples should be increased.
+	BitsPerCollect float64
+}
+
+// NewJitter returns a Jitter collector which takes 512 samples every
+// second.
+func NewJitter() *Jitter {
+	return &Jitter{
+		Samples: 512,
+		Every:   time.Second,
+	}
+}
+
+// Collect measures j.Samples timing samples and returns the accepted
+// samples, each encoded as 8 bytes.
+func (j *Jitter) Collect(ctx context.Context) ([]byte, error) {
+	err := ctx.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if j.mem == nil {
+		j.mem = make([]byte, jitterMemSize)
+	}
+	n := j.Samples
+	if n < 1 {
+		n = 1
+	}
+	// two extra samples are needed for the stuck test of the first
+	// sample
+	x := make([]int64, n+2)
+	for i := range x {
+		x[i] = j.measure()
+	}
+
+	accepted, stuck, err := checkSamples(x)
+	j.stats.Samples += len(x)
+	j.stats.Stuck += stuck
+	if err != nil {
+		j.stats.Failures++
+		return nil, err
+	}
+
+	h := mcvEstimate(accepted)
+	j.stats.MinEntropy = h
+	bits := h * float64(len(accepted))
+	if bits > maxBitsPerCollect {
+		bits = maxBitsPerCollect
+	}
+	j.stats.BitsPerCollect = bits
+
+	res := make([]byte, 8*len(accepted))
+	for i, d := range accepted {
+		binary.LittleEndian.PutUint64(res[8*i:], uint64(d))
+	}
+	return res, nil
+}
+
+// Interval returns the suggested time between two calls to Collect().
+func (j *Jitter) Interval() time.Duration {
+	return j.Every
+}
+
+// Name returns "cpu jitter", for diagnostics.
+func (j *Jitter) Name() string {
+	return "cpu jitter"
+}
+
+// Stats returns statistics about the samples taken so far.
+func (j *Jitter) Stats() JitterStats {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	return j.stats
+}
+
+// measure returns the time, in nanoseconds, needed for one round of
+// memory accesses and hash computations.
+func (j *Jitter) measure() int64 {
+	start := time.Now()
+
+	pos := j.pos
+	for i := 0; i < jitterMemSteps; i++ {
+		j.mem[pos]++
+		pos = (pos + jitterStride) % len(j.mem)
+	}
+	j.pos = pos
+	for i := 0; i < jitterHashRounds; i++ {
+		j.scratch = sha256.Sum256(j.scratch[:])
+	}
+
+	return int64(time.Since(start))
+}
+
+// checkSamples applies the health tests to the timing samples x.  The
+// first two samples are only used as a reference for the stuck test of
+// the following samples.  The samples which pass the stuck test are
+// returned, together with the number of stuck samples.  If fewer than
+// half of the samples pass the stuck test, or if the repetition count
+// test or the adaptive proportion test fails, an error is returned.
+func checkSamples(x []int64) ([]int64, int, error) {
+	if len(x) < 3 {
+		return nil, 0, ErrJitterStuck
+	}
+
+	var accepted []int64
+	stuck := 0
+	for i := 2; i < len(x); i++ {
+		d2 := x[i] - x[i-1]
+		d3 := d2 - (x[i-1] - x[i-2])
+		if x[i] == 0 || d2 == 0 || d3 == 0 {
+			stuck++
+			continue
+		}
+		accepted = append(accepted, x[i])
+	}
+
+	// repetition count test
+	run := 1
+	for i := 1; i < len(x); i++ {
+		if x[i] == x[i-1] {
+			run++
+			if run >= rctCutoff {
+				return nil, stuck, fmt.Errorf("%w: %d identical samples",
+					ErrJitterHealth, run)
+			}
+		} else {
+			run = 1
+		}
+	}
+
+	// adaptive proportion test
+	for start := 0; start+aptWindow <= len(x); start += aptWindow {
+		count := 0
+		for _, v := range x[start : start+aptWindow] {
+			if v == x[start] {
+				count++
+			}
+		}
+		if count >= aptCutoff {
+			return nil, stuck, fmt.Errorf("%w: value repeated %d times in %d samples",
+				ErrJitterHealth, count, aptWindow)
+		}
+	}
+
+	if 2*len(accepted) < len(x)-2 {
+		return nil, stuck, ErrJitterStuck
+	}
+	return accepted, stuck, nil
+}
+
+// mcvEstimate returns the most common value estimate of the min-entropy
+// per sample, in bits, from NIST SP 800-90B, section 6.3.1.
+func mcvEstimate(x []int64) float64 {
+	n := len(x)
+	if n < 2 {
+		return 0
+	}
+	counts := make(map[int64]int)
+	maxCount := 0
+	for _, v := range x {
+		counts[v]++
+		if counts[v] > maxCount {
+			maxCount = counts[v]
+		}
+	}
+	p := float64(maxCount) / float64(n)
+	pu := p + 2.576*math.Sqrt(p*(1-p)/float64(n-1))
+	if pu >= 1 {
+		return 0
+	}
+	return -math.Log2(pu)
+}
@@ -0,0 +1,155 @@
+// jitter_test.go - unit tests for jitter.go
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package collectors
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/seehuhn/fortuna"
+)
+
+func TestJitterCollect(t *testing.T) {
+	c := NewJitter()
+	c.Samples = 64
+	data, err := c.Collect(context.Background())
+	if errors.Is(err, ErrJitterStuck) {
+		t.Skip("clock too coarse for jitter entropy")
+	} else if err != nil {
+		t.Fatal(err)
+	}
+
+	stats := c.Stats()
+	if stats.Samples != 66 {
+		t.Errorf("wrong number of samples %d", stats.Samples)
+	}
+	if len(data) != 8*(stats.Samples-2-stats.Stuck) {
+		t.Errorf("wrong data length %d, %d samples stuck",
+			len(data), stats.Stuck)
+	}
+	if stats.MinEntropy < 0 || stats.MinEntropy > 64 {
+		t.Errorf("invalid min-entropy estimate %g", stats.MinEntropy)
+	}
+	bits := stats.MinEntropy * float64(len(data)/8)
+	if bits > maxBitsPerCollect {
+		bits = maxBitsPerCollect
+	}
+	if stats.BitsPerCollect != bits {
+		t.Errorf("wrong entropy per collect %g != %g",
+			stats.BitsPerCollect, bits)
+	}
+	if c.Interval() <= 0 {
+		t.Errorf("invalid interval %v", c.Interval())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = c.Collect(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled context: wrong error %v", err)
+	}
+}
+
+func TestJitterHealthTests(t *testing.T) {
+	// constant timings fail the repetition count test
+	x := make([]int64, 100)
+	for i := range x {
+		x[i] = 1000
+	}
+	_, stuck, err := checkSamples(x)
+	if !errors.Is(err, ErrJitterHealth) {
+		t.Errorf("constant: wrong error %v", err)
+	}
+	if stuck != 98 {
+		t.Errorf("constant: wrong stuck count %d", stuck)
+	}
+
+	// a frequent value fails the adaptive proportion test
+	x = make([]int64, aptWindow)
+	for i := range x {
+		x[i] = 1000
+		if i%5 == 4 {
+			x[i] = int64(2000 + i)
+		}
+	}
+	_, _, err = checkSamples(x)
+	if !errors.Is(err, ErrJitterHealth) {
+		t.Errorf("frequent value: wrong error %v", err)
+	}
+
+	// linearly increasing timings have a constant first difference
+	for i := range x {
+		x[i] = int64(1000 + 3*i)
+	}
+	_, _, err = checkSamples(x)
+	if err != ErrJitterStuck {
+		t.Errorf("linear: wrong error %v", err)
+	}
+
+	// alternating timings pass all tests
+	for i := range x {
+		x[i] = int64(1000 + 7*(i%2))
+	}
+	accepted, stuck, err := checkSamples(x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stuck != 0 || len(accepted) != len(x)-2 {
+		t.Errorf("alternating: %d accepted, %d stuck", len(accepted), stuck)
+	}
+}
+
+func TestMCVEstimate(t *testing.T) {
+	x := make([]int64, 1000)
+	if h := mcvEstimate(x); h != 0 {
+		t.Errorf("constant: wrong estimate %g", h)
+	}
+
+	for i := range x {
+		x[i] = int64(i % 2)
+	}
+	expected := -math.Log2(0.5 + 2.576*math.Sqrt(0.25/999))
+	if h := mcvEstimate(x); math.Abs(h-expected) > 1e-9 {
+		t.Errorf("two values: wrong estimate %g != %g", h, expected)
+	}
+
+	for i := range x {
+		x[i] = int64(i)
+	}
+	if h := mcvEstimate(x); h < 5 || h > math.Log2(1000) {
+		t.Errorf("distinct values: wrong estimate %g", h)
+	}
+}
+
+func TestJitterAccumulator(t *testing.T) {
+	acc, err := fortuna.NewAccumulatorWithOptions(fortuna.WithMaxSources(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewJitter()
+	c.Samples = 16
+	err = acc.AddCollector(c)
+	if err != nil {
+		t.Error(err)
+	}
+	err = acc.Close()
+	if err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,193 @@
+// combiner.go - a generator combining the output of two block ciphers
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package fortuna
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// combinerChunk is the maximal number of bytes generated at a time by
+// each of the two generators of a CombinedGenerator.
+const combinerChunk = 1 << 16
+
+// CombinedGenerator runs two Fortuna Generators, using different block
+// ciphers, and returns the XOR of their outputs.  The output is
+// unpredictable as long as at least one of the two ciphers is secure,
+// so that a break of one cipher does not compromise the generator.
+//
+// The two generators are seeded independently, and every seed given to
+// Reseed() is combined with a different label for each generator, so
+// that the keys of the two generators are never equal.  Requests are
+// split into chunks of at most 64 KiB, and both generators are rekeyed
+// after every chunk.
+//
+// A CombinedGenerator is not safe for use with concurrent access.
+type CombinedGenerator struct {
+	gen     [2]*Generator
+	scratch []byte
+}
+
+// combinerLabels are prepended to the seeds of the two generators.
+var combinerLabels = [2]byte{'A', 'B'}
+
+// NewCombinedGenerator allocates a new CombinedGenerator which XORs the
+// output of a Generator using the block cipher newCipher1 with the
+// output of a Generator using newCipher2.  The options opts are applied
+// to both generators.  Errors are reported as for NewGeneratorE().  If
+// both functions return the same cipher, so that the combination would
+// be no stronger than a single Generator, an error wrapping
+// ErrCipherInit is returned.
+func NewCombinedGenerator(newCipher1, newCipher2 NewCipher, opts ...GeneratorOption) (*CombinedGenerator, error) {
+	comb := &CombinedGenerator{}
+	for i, newCipher := range []NewCipher{newCipher1, newCipher2} {
+		gen, err := NewGeneratorE(newCipher, opts...)
+		if err != nil {
+			if i > 0 {
+				comb.gen[0].Destroy()
+			}
+			return nil, err
+		}
+		comb.gen[i] = gen
+	}
+
+	var ids [2][]byte
+	for i, gen := range comb.gen {
+		id, err := gen.cipherID()
+		if err != nil {
+			comb.Destroy()
+			return nil, fmt.Errorf("%w: %v", ErrCipherInit, err)
+		}
+		ids[i] = id
+	}
+	if bytes.Equal(ids[0], ids[1]) {
+		comb.Destroy()
+		return nil, fmt.Errorf("%w: both generators use the same cipher",
+			ErrCipherInit)
+	}
+	return comb, nil
+}
+
+// EnableHealthTest switches on the continuous health test for both
+// generators, see Generator.EnableHealthTest().
+func (comb *CombinedGenerator) EnableHealthTest() {
+	for _, gen := range comb.gen {
+		gen.EnableHealthTest()
+	}
+}
+
+// reset reverts both generators to the unseeded state and wipes the
+// keys.
+func (comb *CombinedGenerator) reset() {
+	for _, gen := range comb.gen {
+		if gen != nil {
+			gen.reset()
+		}
+	}
+	wipe(comb.scratch)
+}
+
+// Destroy destroys both generators, see Generator.Destroy(), and wipes
+// the scratch space.  Afterwards, Fill() and ReseedE() return
+// ErrDestroyed, and all other methods panic.
+func (comb *CombinedGenerator) Destroy() {
+	for _, gen := range comb.gen {
+		gen.Destroy()
+	}
+	wipe(comb.scratch)
+	comb.scratch = nil
+}
+
+// Reseed uses the current generator state and the given seed value to
+// update the state of both generators.  Reseed panics if one of the
+// block ciphers cannot be initialised with the new key.  Use ReseedE()
+// to get an error instead.
+func (comb *CombinedGenerator) Reseed(seed []byte) {
+	err := comb.ReseedE(seed)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// ReseedE is like Reseed(), but reports failures as an error instead
+// of panicking.
+func (comb *CombinedGenerator) ReseedE(seed []byte) error {
+	labelled := make([]byte, 1+len(seed))
+	copy(labelled[1:], seed)
+	defer wipe(labelled)
+	for i, gen := range comb.gen {
+		labelled[0] = combinerLabels[i]
+		err := gen.ReseedE(labelled)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Fill fills dst with the XOR of the outputs of the two generators.
+// If the generators have not been seeded, ErrNotSeeded is returned.
+// If one of the generators fails, dst is wiped and the error is
+// returned.
+func (comb *CombinedGenerator) Fill(dst []byte) error {
+	size := len(dst)
+	if size > combinerChunk {
+		size = combinerChunk
+	}
+	if len(comb.scratch) < size {
+		comb.scratch = make([]byte, size)
+	}
+
+	for len(dst) > 0 {
+		n := len(dst)
+		if n > combinerChunk {
+			n = combinerChunk
+		}
+		buf := comb.scratch[:n]
+		err := comb.gen[0].Fill(dst[:n])
+		if err == nil {
+			err = comb.gen[1].Fill(buf)
+		}
+		if err != nil {
+			wipe(dst)
+			wipe(buf)
+			return err
+		}
+		for i, x := range buf {
+			dst[i] ^= x
+		}
+		wipe(buf)
+		dst = dst[n:]
+	}
+	return nil
+}
+
+// FillBytes is like Fill(), but panics if an error occurs.
+func (comb *CombinedGenerator) FillBytes(dst []byte) {
+	err := comb.Fill(dst)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// PseudoRandomData returns a slice of n pseudo-random bytes.  The
+// method panics if an error occurs.
+func (comb *CombinedGenerator) PseudoRandomData(n uint) []byte {
+	res := make([]byte, n)
+	comb.FillBytes(res)
+	return res
+}
@@ -0,0 +1,114 @@
+// combiner_test.go - unit tests for combiner.go
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package fortuna
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/des"
+	"errors"
+	"testing"
+)
+
+func TestCombinedGenerator(t *testing.T) {
+	comb, err := NewCombinedGenerator(aes.NewCipher, des.NewTripleDESCipher,
+		AllowSmallBlocks())
+	if err != nil {
+		t.Fatal(err)
+	}
+	comb.Reseed([]byte{1, 2, 3})
+	if bytes.Equal(comb.gen[0].key[:24], comb.gen[1].key) {
+		t.Error("both generators use the same key")
+	}
+
+	// copy the state of both generators
+	var gen [2]*Generator
+	newCiphers := []NewCipher{aes.NewCipher, des.NewTripleDESCipher}
+	for i := range gen {
+		state, err := comb.gen[i].MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		gen[i] = NewGenerator(newCiphers[i], AllowSmallBlocks())
+		err = gen[i].UnmarshalBinary(state)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, n := range []int{1, 100, combinerChunk + 17} {
+		out := comb.PseudoRandomData(uint(n))
+		for len(out) > 0 {
+			k := len(out)
+			if k > combinerChunk {
+				k = combinerChunk
+			}
+			x := gen[0].PseudoRandomData(uint(k))
+			y := gen[1].PseudoRandomData(uint(k))
+			for i := range x {
+				if out[i] != x[i]^y[i] {
+					t.Fatalf("%d: wrong output", n)
+				}
+			}
+			out = out[k:]
+		}
+	}
+
+	_, err = NewCombinedGenerator(aes.NewCipher, des.NewTripleDESCipher)
+	if !errors.Is(err, ErrWeakCipher) {
+		t.Errorf("64 bit cipher not rejected: %v", err)
+	}
+	_, err = NewCombinedGenerator(aes.NewCipher, aes.NewCipher)
+	if !errors.Is(err, ErrCipherInit) {
+		t.Errorf("identical ciphers not rejected: %v", err)
+	}
+	_, err = NewAccumulatorWithOptions(WithCombinedCipher(aes.NewCipher))
+	if !errors.Is(err, ErrCipherInit) {
+		t.Errorf("identical ciphers not rejected by the Accumulator: %v", err)
+	}
+}
+
+func TestCombinedHealthTest(t *testing.T) {
+	comb, err := NewCombinedGenerator(aes.NewCipher, newStuckCipher)
+	if err != nil {
+		t.Fatal(err)
+	}
+	comb.EnableHealthTest()
+	err = comb.Fill(make([]byte, 64))
+	if !errors.Is(err, ErrHealthTest) {
+		t.Errorf("stuck cipher not detected: %v", err)
+	}
+}
+
+func TestAccumulatorCombined(t *testing.T) {
+	acc, err := NewAccumulatorWithOptions(
+		WithCombinedCipher(des.NewTripleDESCipher),
+		WithGeneratorOptions(AllowSmallBlocks()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := acc.gen.(*CombinedGenerator); !ok {
+		t.Errorf("wrong generator type %T", acc.gen)
+	}
+	acc.addRandomEvent(0, 0, make([]byte, 32))
+	x := acc.RandomData(100)
+	y := acc.RandomData(100)
+	if bytes.Equal(x, y) {
+		t.Error("repeated output")
+	}
+	acc.Close()
+}
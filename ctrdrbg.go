@@ -0,0 +1,323 @@
+// ctrdrbg.go - the CTR_DRBG from NIST SP 800-90A
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package fortuna
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"fmt"
+)
+
+// ctrKeySize is the key length of the CTR_DRBG in bytes.  This
+// corresponds to AES-256, giving a security strength of 256 bits.
+const ctrKeySize = 32
+
+// CTRDRBG implements the CTR_DRBG mechanism from NIST Special
+// Publication 800-90A, using a block cipher with 256 bit keys (AES-256
+// in the standard) and the block cipher derivation function.
+//
+// A new CTRDRBG must be instantiated using the Instantiate() method
+// before it can be used.  The methods Instantiate(), Reseed(),
+// Generate() and Uninstantiate() correspond to the functions of the
+// same names in SP 800-90A; prediction resistance is not supported.
+//
+// Like Generator, CTRDRBG is not safe for use with concurrent access.
+type CTRDRBG struct {
+	newCipher     NewCipher
+	block         cipher.Block
+	key           []byte
+	v             []byte
+	reseedCounter uint64
+}
+
+// NewCTRDRBG allocates a new CTR_DRBG which uses the given block
+// cipher.  newCipher must accept 32 byte keys and should normally be
+// aes.NewCipher.  The new DRBG must be instantiated before use.
+func NewCTRDRBG(newCipher NewCipher) *CTRDRBG {
+	return &CTRDRBG{
+		newCipher: newCipher,
+	}
+}
+
+func (d *CTRDRBG) seedLen() int {
+	return ctrKeySize + len(d.v)
+}
+
+// setKey keys the block cipher.
+func (d *CTRDRBG) setKey(key []byte) error {
+	block, err := d.newCipher(key)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrCipherInit, err)
+	}
+	if d.key == nil {
+		d.key = make([]byte, ctrKeySize)
+	}
+	copy(d.key, key)
+	d.block = block
+	return nil
+}
+
+// incV increments V as a big-endian number, modulo 2^blocklen.
+func (d *CTRDRBG) incV() {
+	for i := len(d.v) - 1; i >= 0; i-- {
+		d.v[i]++
+		if d.v[i] != 0 {
+			break
+		}
+	}
+}
+
+// update implements CTR_DRBG_Update.  The length of providedData must
+// be seedLen().
+func (d *CTRDRBG) update(providedData []byte) error {
+	n := len(d.v)
+	temp := make([]byte, (d.seedLen()+n-1)/n*n)
+	defer wipe(temp)
+	for i := 0; i < len(temp); i += n {
+		d.incV()
+		d.block.Encrypt(temp[i:], d.v)
+	}
+	for i, x := range providedData {
+		temp[i] ^= x
+	}
+
+	err := d.setKey(temp[:ctrKeySize])
+	if err != nil {
+		return err
+	}
+	copy(d.v, temp[ctrKeySize:d.seedLen()])
+	return nil
+}
+
+// bcc implements the BCC function from SP 800-90A, section 10.3.3.
+func bcc(block cipher.Block, data []byte, out []byte) {
+	n := block.BlockSize()
+	for i := range out {
+		out[i] = 0
+	}
+	for len(data) > 0 {
+		for i := 0; i < n; i++ {
+			out[i] ^= data[i]
+		}
+		block.Encrypt(out, out)
+		data = data[n:]
+	}
+}
+
+// derive implements the Block_Cipher_df function from SP 800-90A,
+// section 10.3.2.  The inputs are concatenated and seedLen() bytes
+// are returned.
+func (d *CTRDRBG) derive(inputs ...[]byte) ([]byte, error) {
+	n := len(d.v)
+	seedLen := d.seedLen()
+
+	inputLen := 0
+	for _, in := range inputs {
+		inputLen += len(in)
+	}
+
+	// The data for BCC is IV || S, where S = L || N || input || 0x80,
+	// padded with zeros to a multiple of the block length.
+	sLen := 4 + 4 + inputLen + 1
+	sLen = (sLen + n - 1) / n * n
+	data := make([]byte, n+sLen)
+	defer wipe(data)
+	s := data[n:]
+	binary.BigEndian.PutUint32(s[0:4], uint32(inputLen))
+	binary.BigEndian.PutUint32(s[4:8], uint32(seedLen))
+	pos := 8
+	for _, in := range inputs {
+		pos += copy(s[pos:], in)
+	}
+	s[pos] = 0x80
+
+	k := make([]byte, ctrKeySize)
+	for i := range k {
+		k[i] = byte(i)
+	}
+	block, err := d.newCipher(k)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCipherInit, err)
+	}
+
+	temp := make([]byte, (ctrKeySize+n+n-1)/n*n)
+	defer wipe(temp)
+	for i := 0; i*n < len(temp); i++ {
+		binary.BigEndian.PutUint32(data[0:4], uint32(i))
+		bcc(block, data, temp[i*n:(i+1)*n])
+	}
+
+	block, err = d.newCipher(temp[:ctrKeySize])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCipherInit, err)
+	}
+	x := temp[ctrKeySize : ctrKeySize+n]
+
+	res := make([]byte, (seedLen+n-1)/n*n)
+	for i := 0; i < len(res); i += n {
+		block.Encrypt(x, x)
+		copy(res[i:], x)
+	}
+	return res[:seedLen], nil
+}
+
+// Instantiate sets the initial state of the DRBG.  The entropy input
+// must be at least 32 bytes long and the nonce at least 16 bytes,
+// otherwise ErrShortInput is returned.  The personalization string is
+// optional and may be nil.  Instantiate can also be used to restart an
+// existing DRBG from a new state.
+func (d *CTRDRBG) Instantiate(entropy, nonce, personalization []byte) error {
+	if len(entropy) < drbgSecurityStrength ||
+		len(nonce) < drbgSecurityStrength/2 {
+		return ErrShortInput
+	}
+
+	err := d.setKey(make([]byte, ctrKeySize))
+	if err != nil {
+		return err
+	}
+	d.v = make([]byte, d.block.BlockSize())
+
+	seedMaterial, err := d.derive(entropy, nonce, personalization)
+	if err != nil {
+		return err
+	}
+	defer wipe(seedMaterial)
+	err = d.update(seedMaterial)
+	if err != nil {
+		return err
+	}
+	d.reseedCounter = 1
+	return nil
+}
+
+// Reseed mixes new entropy input, and optional additional input, into
+// the state of the DRBG.  The entropy input must be at least 32 bytes
+// long, otherwise ErrShortInput is returned.  If the DRBG has not been
+// instantiated, ErrNotSeeded is returned.
+func (d *CTRDRBG) Reseed(entropy, additional []byte) error {
+	if d.reseedCounter == 0 {
+		return ErrNotSeeded
+	}
+	if len(entropy) < drbgSecurityStrength {
+		return ErrShortInput
+	}
+
+	seedMaterial, err := d.derive(entropy, additional)
+	if err != nil {
+		return err
+	}
+	defer wipe(seedMaterial)
+	err = d.update(seedMaterial)
+	if err != nil {
+		return err
+	}
+	d.reseedCounter = 1
+	return nil
+}
+
+// Generate fills out with pseudo random bytes.  The optional
+// additional input is mixed into the state before the output is
+// generated.  At most 65536 bytes can be requested at a time, longer
+// requests fail with ErrRequestTooLarge.  If the DRBG has not been
+// instantiated, ErrNotSeeded is returned.  If the DRBG must be
+// reseeded, ErrReseedRequired is returned.
+func (d *CTRDRBG) Generate(out, additional []byte) error {
+	if d.reseedCounter == 0 {
+		return ErrNotSeeded
+	}
+	if d.reseedCounter > drbgReseedInterval {
+		return ErrReseedRequired
+	}
+	if len(out) > drbgMaxRequest {
+		return ErrRequestTooLarge
+	}
+
+	var err error
+	if len(additional) > 0 {
+		additional, err = d.derive(additional)
+		if err != nil {
+			return err
+		}
+		defer wipe(additional)
+		err = d.update(additional)
+		if err != nil {
+			return err
+		}
+	} else {
+		additional = make([]byte, d.seedLen())
+	}
+
+	n := len(d.v)
+	for len(out) > 0 {
+		d.incV()
+		if len(out) >= n {
+			d.block.Encrypt(out, d.v)
+			out = out[n:]
+		} else {
+			buf := make([]byte, n)
+			d.block.Encrypt(buf, d.v)
+			copy(out, buf)
+			wipe(buf)
+			out = nil
+		}
+	}
+
+	err = d.update(additional)
+	if err != nil {
+		return err
+	}
+	d.reseedCounter++
+	return nil
+}
+
+// Uninstantiate wipes the internal state of the DRBG.  The DRBG must
+// be instantiated again before it can be used.
+func (d *CTRDRBG) Uninstantiate() {
+	wipe(d.key)
+	wipe(d.v)
+	d.block = nil
+	d.reseedCounter = 0
+}
+
+// ReseedE reseeds the DRBG, using seed as the entropy input and no
+// additional input.  Together with Fill(), this allows to use a
+// CTRDRBG as the generator of an Accumulator.
+func (d *CTRDRBG) ReseedE(seed []byte) error {
+	return d.Reseed(seed, nil)
+}
+
+// Fill fills dst with pseudo random bytes, using as many calls to
+// Generate() as required.  No additional input is used.
+func (d *CTRDRBG) Fill(dst []byte) error {
+	return drbgFill(d, dst)
+}
+
+func (d *CTRDRBG) reset() {
+	d.Uninstantiate()
+}
+
+// newSeededCTRDRBG allocates a new CTR_DRBG and instantiates it using
+// the initial seed data of the system.
+func newSeededCTRDRBG(newCipher NewCipher, personalization []byte) (*CTRDRBG, error) {
+	d := NewCTRDRBG(newCipher)
+	err := instantiateFromSystem(d, personalization)
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
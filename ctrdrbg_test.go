@@ -0,0 +1,240 @@
+// ctrdrbg_test.go - unit tests for ctrdrbg.go
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package fortuna
+
+import (
+	"bytes"
+	"crypto/aes"
+	"testing"
+)
+
+// ctrDRBGVectors are taken from the sections "[AES-256 use df]" with
+// "[PredictionResistance = False]" of the CAVP files
+// no_reseed/CTR_DRBG.rsp and pr_false/CTR_DRBG.rsp.
+var ctrDRBGVectors = []drbgTestVector{
+	{
+		entropyInput:          "36401940fa8b1fba91a1661f211d78a0b9389a74e5bccfece8d766af1a6d3b14",
+		nonce:                 "496f25b0f1301b4f501be30380a137eb",
+		personalizationString: "",
+		additionalInput:       [2]string{"", ""},
+		returnedBits:          "5862eb38bd558dd978a696e6df164782ddd887e7e9a6c9f3f1fbafb78941b535a64912dfd224c6dc7454e5250b3d97165e16260c2faf1cc7735cb75fb4f07e1d",
+	},
+	{
+		entropyInput:          "13199090a47fbd1984eb5fa9589345154699ef73f00cd62b07c34167c0327e53",
+		nonce:                 "5f968f93b659d8a5750a95345a8ae20c",
+		personalizationString: "",
+		additionalInput:       [2]string{"", ""},
+		returnedBits:          "d16878c5b06d7b6ced8e8aeb3a48d95ec8dd655733eec6ef473a8078dfdea600c0cc02168b4d6d744ee828ba5031941f8e3d96586407af79eba60d14af47d53a",
+	},
+	{
+		entropyInput:          "8148d65d86513ce7d38923ec2f26b9e7c677dcc8997e325b7372619e753ed944",
+		nonce:                 "41c71a24d17d974190982bb7515ce7f5",
+		personalizationString: "",
+		additionalInput:       [2]string{"55b446046c2d14bdd0cdba4b71873fd4762650695a11507949462da8d964ab6a", "91468f1a097d99ee339462ca916cb4a10f63d53850a4f17f598eac490299b02e"},
+		returnedBits:          "54603d1a506132bbfa05b153a04f22a1d516cc46323cef15111af221f030f38d6841d4670518b4914a4631af682e7421dffaac986a38e94d92bfa758e2eb101f",
+	},
+	{
+		entropyInput:          "eb4a0add697097f1ce3a719d0d4ae69b1721dce3ec0e6c0e905d78ee212863b1",
+		nonce:                 "5f368e85c1f17b6463a278377f691f37",
+		personalizationString: "",
+		additionalInput:       [2]string{"f97801bce981b35081c25801400ec207433da4f17f3265a16e9e4e683722708b", "ae54b49a4112b3d978e966e2dda062e3652b58a14bef4ffe038520c9a675d353"},
+		returnedBits:          "6aee0b3a815c82f9bb0119f86af90793fc1f9996dd5b72bbc326ac4e6a5e874850b2fec1d7202c35580bd6727029609f2471e6c9b61629d174b894cd178adfd4",
+	},
+	{
+		entropyInput:          "5416e77b5e1d872d4ff91973b1be66bc07f4a99e30db7d0006da006fcfb082db",
+		nonce:                 "7a811ce62b9fd34af186b2b3e50eaf5d",
+		personalizationString: "71ee0c7699ac0e805632f2058de38bf872b8340f89998f7a8a2ad4ac045ae6ef",
+		additionalInput:       [2]string{"", ""},
+		returnedBits:          "68f5859cf76f94c445d9fcd34fc17ac224c3d7d7c2fc38faaf3c24be6cd3cd93b7f9d8a6146f5ac83ac1d7b1b2b7e7ecbc1a2e38760ef86a577d402d85990d9b",
+	},
+	{
+		entropyInput:          "708eca2e3a9265a790607edbe05fe342663f84c6617eda14f25276a943901fda",
+		nonce:                 "75afb49a184b23506be14926cd4a03f0",
+		personalizationString: "cbb48ef84146c10e02240d8740d3487b6a4208405383c01a664ec7d3ada07e2d",
+		additionalInput:       [2]string{"", ""},
+		returnedBits:          "26b0aa6e822c4cc912cf1dbae669c7dad0bdcff65f22813afd06225b7ff799f7803b3ad48bc88d2be0f5a357f620cc617f446fc6d212592ada69b7dc8ff4a222",
+	},
+	{
+		entropyInput:          "87b56e964eba227154724bb9484b812d3e2c0c43b3d17f6098d9526e16e6d0ef",
+		nonce:                 "9bea6a7ff2358df142e6c23e2157fb83",
+		personalizationString: "9860b432edd58d1ccbfeecbce99ffaee7d935a614860d4e965bd67041403096b",
+		additionalInput:       [2]string{"99a5cc87924e8ea65a596f81fd17d63f5b4542fe6e8e1511b5d35c835dfadb0b", "9a8dec54734a34582a2332f3452e82313524c3e0dfb485faeac6ca5fc0ff504d"},
+		returnedBits:          "dbc6a2330b19b5cddd8cd6392ec1fb508678c805e87d1aca07ac265007632503044a00610c79d98375afa7ab4cca1a90989cbfe7c674af5d823ced11c47e9af6",
+	},
+	{
+		entropyInput:          "b36032f5d777250826d831566ec585452d70b920654355acf8f691941643ee95",
+		nonce:                 "dacf747e85faa6a3eb016df929c90e8b",
+		personalizationString: "f03265b2f2174cea938ff23c7e60a75dcba1e4e412bbad4b5d3b3e23685e80d8",
+		additionalInput:       [2]string{"d4772380de774bbbb6100d9339590eff033ff548b826685553a2e857800a07e2", "05011d3dd4ddcf19076fae656973aac9a11641b210963cec81d1ea58db7bb7e0"},
+		returnedBits:          "3d3531057977401072ce44e2e66317a808d47c44aad4f98c08d88eac7b598c40714ad12417b61699d1126ea4c642b09fe9f5ded36f2e37ed2cce972e0dfcc7ce",
+	},
+	{
+		entropyInput:          "2d4c9f46b981c6a0b2b5d8c69391e569ff13851437ebc0fc00d616340252fed5",
+		nonce:                 "0bf814b411f65ec4866be1abb59d3c32",
+		personalizationString: "",
+		entropyInputReseed:    "93500fae4fa32b86033b7a7bac9d37e710dcc67ca266bc8607d665937766d207",
+		additionalInputReseed: "",
+		additionalInput:       [2]string{"", ""},
+		returnedBits:          "322dd28670e75c0ea638f3cb68d6a9d6e50ddfd052b772a7b1d78263a7b8978b6740c2b65a9550c3a76325866fa97e16d74006bc96f26249b9f0a90d076f08e5",
+	},
+	{
+		entropyInput:          "6f60f0f9d486bc23e1223b934e61c0c78ae9232fa2e9a87c6dacd447c3f10e9e",
+		nonce:                 "401e3f87762fa8a14ab232ccb8480a2f",
+		personalizationString: "",
+		entropyInputReseed:    "350be52552a65a804a106543ebb7dd046cffae104e4e8b2f18936d564d3c1950",
+		additionalInputReseed: "7a3688adb1cfb6c03264e2762ece96bfe4daf9558fabf74d7fff203c08b4dd9f",
+		additionalInput:       [2]string{"67cf4a56d081c53670f257c25557014cd5e8b0e919aa58f23d6861b10b00ea80", "648d4a229198b43f33dd7dd8426650be11c5656adcdf913bb3ee5eb49a2a3892"},
+		returnedBits:          "2d819fb9fee38bfc3f15a07ef0e183ff36db5d3184cea1d24e796ba103687415abe6d9f2c59a11931439a3d14f45fc3f4345f331a0675a3477eaf7cd89107e37",
+	},
+	{
+		entropyInput:          "5bb14bec3a2e435acab8b891f075107df387902cb2cd996021b1a1245d4ea2b5",
+		nonce:                 "12ac7f444e247f770d2f4d0a65fdab4e",
+		personalizationString: "2e957d53cba5a6b9b8a2ce4369bb885c0931788015b9fe5ac3c01a7ec5eacd70",
+		entropyInputReseed:    "19f30c84f6dbf1caf68cbec3d4bb90e5e8f5716eae8c1bbadaba99a2a2bd4eb2",
+		additionalInputReseed: "",
+		additionalInput:       [2]string{"", ""},
+		returnedBits:          "b7dd8ac2c5eaa97c779fe46cc793b9b1e7b940c318d3b531744b42856f298264e45f9a0aca5da93e7f34f0ebc0ed0ea32c009e3e03cf01320c9a839807575405",
+	},
+	{
+		entropyInput:          "174b46250051a9e3d80c56ae7163dafe7e54481a56cafd3b8625f99bbb29c442",
+		nonce:                 "98ffd99c466e0e94a45da7e0e82dbc6b",
+		personalizationString: "7095268e99938b3e042734b9176c9aa051f00a5f8d2a89ada214b89beef18ebf",
+		entropyInputReseed:    "e88be1967c5503f65d23867bbc891bd679db03b4878663f6c877592df25f0d9a",
+		additionalInputReseed: "cdf6ad549e45b6aa5cd67d024931c33cd133d52d5ae500c3015020beb30da063",
+		additionalInput:       [2]string{"c7228e90c62f896a09e11684530102f926ec90a3255f6c21b857883c75800143", "76a94f224178fe4cbf9e2b8acc53c9dc3e50bb613aac8936601453cda3293b17"},
+		returnedBits:          "1a6d8dbd642076d13916e5e23038b60b26061f13dd4e006277e0268698ffb2c87e453bae1251631ac90c701a9849d933995e8b0221fe9aca1985c546c2079027",
+	},
+}
+
+func TestCTRDRBGVectors(t *testing.T) {
+	testDRBGVectors(t, func() drbg { return NewCTRDRBG(aes.NewCipher) },
+		ctrDRBGVectors)
+}
+
+func TestCTRDRBGErrors(t *testing.T) {
+	d := NewCTRDRBG(aes.NewCipher)
+	buf := make([]byte, 16)
+	if err := d.Generate(buf, nil); err != ErrNotSeeded {
+		t.Errorf("uninstantiated DRBG not detected: %v", err)
+	}
+
+	entropy := make([]byte, 32)
+	nonce := make([]byte, 16)
+	if err := d.Instantiate(entropy[:31], nonce, nil); err != ErrShortInput {
+		t.Errorf("short entropy input not detected: %v", err)
+	}
+	if err := d.Instantiate(entropy, nonce[:15], nil); err != ErrShortInput {
+		t.Errorf("short nonce not detected: %v", err)
+	}
+	if err := d.Instantiate(entropy, nonce, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Reseed(entropy[:31], nil); err != ErrShortInput {
+		t.Errorf("short entropy input not detected: %v", err)
+	}
+	if err := d.Generate(make([]byte, drbgMaxRequest+1), nil); err != ErrRequestTooLarge {
+		t.Errorf("large request not detected: %v", err)
+	}
+
+	d.reseedCounter = drbgReseedInterval + 1
+	if err := d.Generate(buf, nil); err != ErrReseedRequired {
+		t.Errorf("exhausted reseed interval not detected: %v", err)
+	}
+	if err := d.Reseed(entropy, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Generate(buf, nil); err != nil {
+		t.Error(err)
+	}
+
+	d.Uninstantiate()
+	if !isZero(d.key) || !isZero(d.v) {
+		t.Error("state not wiped")
+	}
+	if err := d.Generate(buf, nil); err != ErrNotSeeded {
+		t.Errorf("uninstantiated DRBG not detected: %v", err)
+	}
+}
+
+func TestCTRDRBGFill(t *testing.T) {
+	entropy := make([]byte, 32)
+	nonce := make([]byte, 16)
+
+	d1 := NewCTRDRBG(aes.NewCipher)
+	d1.Instantiate(entropy, nonce, nil)
+	out1 := make([]byte, drbgMaxRequest+10)
+	if err := d1.Fill(out1); err != nil {
+		t.Fatal(err)
+	}
+
+	d2 := NewCTRDRBG(aes.NewCipher)
+	d2.Instantiate(entropy, nonce, nil)
+	out2 := make([]byte, drbgMaxRequest+10)
+	d2.Generate(out2[:drbgMaxRequest], nil)
+	d2.Generate(out2[drbgMaxRequest:], nil)
+
+	if !bytes.Equal(out1, out2) {
+		t.Error("Fill and Generate disagree")
+	}
+}
+
+func TestAccumulatorCTRDRBG(t *testing.T) {
+	acc, err := NewAccumulatorWithOptions(WithCTRDRBG([]byte("test")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := acc.gen.(*CTRDRBG); !ok {
+		t.Fatal("wrong generator type")
+	}
+
+	for i := uint(0); i < 100; i++ {
+		acc.addRandomEvent(0, i, make([]byte, 32))
+	}
+	x := acc.RandomData(100)
+	y := acc.RandomData(100)
+	if bytes.Equal(x, y) {
+		t.Error("repeated output")
+	}
+
+	acc.Close()
+	caughtAccessAfterClose := func() (hasPaniced bool) {
+		defer func() {
+			if r := recover(); r != nil {
+				hasPaniced = true
+			}
+		}()
+		acc.RandomData(1)
+		return false
+	}()
+	if !caughtAccessAfterClose {
+		t.Error("failed to detect RNG access after close")
+	}
+}
+
+func BenchmarkCTRDRBG1k(b *testing.B) {
+	d, _ := newSeededCTRDRBG(aes.NewCipher, nil)
+	buffer := make([]byte, 1024)
+
+	b.SetBytes(int64(len(buffer)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		d.Fill(buffer)
+	}
+}
+
+// compile-time test: CTRDRBG implements the RandomGenerator interface
+var _ RandomGenerator = &CTRDRBG{}
@@ -0,0 +1,57 @@
+// destroy.go - wiping the secret state of a generator
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package fortuna
+
+import "errors"
+
+// ErrDestroyed indicates that a generator has been destroyed using
+// its Destroy() method and can no longer be used.
+var ErrDestroyed = errors.New("generator has been destroyed")
+
+// Destroy wipes the key, the counter, all buffered output and all
+// scratch space of the generator, drops the reference to the block
+// cipher, and releases any locked memory (see SecureMemory()).
+// Afterwards the generator is permanently unusable: methods which
+// report errors return ErrDestroyed, and all other methods, like
+// PseudoRandomData(), Seed() or Int63(), panic.  Calling Destroy more
+// than once is harmless.
+//
+// The expanded key schedule inside the cipher.Block cannot be wiped,
+// since the crypto/cipher interfaces give no access to it; it becomes
+// unreachable and is freed by the garbage collector.  Streams and
+// generators obtained from NewStream() and Split() are independent of
+// gen and must be discarded separately.
+func (gen *Generator) Destroy() {
+	for _, buf := range [][]byte{gen.key, gen.counter, gen.block,
+		gen.keyBuf, gen.fkeBuf, gen.prevBlock, gen.intBuf[:]} {
+		wipe(buf)
+	}
+	gen.key = nil
+	gen.counter = nil
+	gen.block = nil
+	gen.keyBuf = nil
+	gen.fkeBuf = nil
+	gen.fkePos = 0
+	gen.prevBlock = nil
+	gen.havePrev = false
+	gen.cipher = nil
+	if gen.mem != nil {
+		gen.mem.free()
+		gen.mem = nil
+	}
+	gen.destroyed = true
+}
@@ -0,0 +1,116 @@
+// destroy_test.go - unit tests for destroy.go
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package fortuna
+
+import (
+	"crypto/aes"
+	"errors"
+	"testing"
+)
+
+func expectPanic(t *testing.T, name string, fn func()) {
+	t.Helper()
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("%s: no panic after Destroy()", name)
+		}
+	}()
+	fn()
+}
+
+func TestDestroy(t *testing.T) {
+	gen := NewGenerator(aes.NewCipher, FastKeyErasure())
+	gen.Seed(1)
+	gen.PseudoRandomData(10)
+	key := gen.key
+	buf := gen.fkeBuf
+
+	gen.Destroy()
+	if !isZero(key) || !isZero(buf) {
+		t.Error("secret data not wiped")
+	}
+	if gen.key != nil || gen.cipher != nil {
+		t.Error("references to secret data retained")
+	}
+
+	if err := gen.Fill(make([]byte, 10)); !errors.Is(err, ErrDestroyed) {
+		t.Errorf("Fill: wrong error %v", err)
+	}
+	if err := gen.ReseedE([]byte{1}); !errors.Is(err, ErrDestroyed) {
+		t.Errorf("ReseedE: wrong error %v", err)
+	}
+	if _, err := gen.NewStream(); !errors.Is(err, ErrDestroyed) {
+		t.Errorf("NewStream: wrong error %v", err)
+	}
+	if _, err := gen.MarshalBinary(); !errors.Is(err, ErrDestroyed) {
+		t.Errorf("MarshalBinary: wrong error %v", err)
+	}
+
+	other := NewGenerator(aes.NewCipher)
+	other.Seed(2)
+	state, err := other.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := gen.UnmarshalBinary(state); !errors.Is(err, ErrDestroyed) {
+		t.Errorf("UnmarshalBinary: wrong error %v", err)
+	}
+
+	expectPanic(t, "Seed", func() { gen.Seed(1) })
+	expectPanic(t, "Int63", func() { gen.Int63() })
+	expectPanic(t, "Split", func() { gen.Split(nil) })
+
+	gen.Destroy()
+}
+
+func TestDestroyOthers(t *testing.T) {
+	chacha, err := NewChaChaGenerator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	comb, err := NewCombinedGenerator(aes.NewCipher, newReversedAES)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, gen := range []interface {
+		RandomGenerator
+		Destroy()
+	}{chacha, comb} {
+		gen.Destroy()
+		if err := gen.Fill(make([]byte, 10)); !errors.Is(err, ErrDestroyed) {
+			t.Errorf("%T.Fill: wrong error %v", gen, err)
+		}
+		if err := gen.ReseedE([]byte{1}); !errors.Is(err, ErrDestroyed) {
+			t.Errorf("%T.ReseedE: wrong error %v", gen, err)
+		}
+	}
+	if !isZero(chacha.key[:]) {
+		t.Error("ChaCha key not wiped")
+	}
+}
+
+func TestAccumulatorCloseDestroys(t *testing.T) {
+	acc, err := NewAccumulatorWithOptions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	acc.Close()
+	if err := acc.gen.Fill(make([]byte, 1)); !errors.Is(err, ErrDestroyed) {
+		t.Errorf("generator not destroyed by Close(): %v", err)
+	}
+}
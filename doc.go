@@ -61,6 +61,14 @@
 // If a seed file is used, the Accumulator must be closed using the
 // Close() method after use.
 //
+// Settings like the block cipher or the number of entropy pools can be
+// changed by allocating the Accumulator using
+// NewAccumulatorWithOptions() instead:
+//
+//     rng, err := fortuna.NewAccumulatorWithOptions(
+//         fortuna.WithSeedFile(seedFileName),
+//         fortuna.WithMinPoolSize(64))
+//
 // Randomness can be extracted from the Accumulator using the
 // RandomData() and Read() methods.  For example, a slice of 16 random
 // bytes can be obtained using the following command:
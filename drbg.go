@@ -0,0 +1,101 @@
+// drbg.go - common code for the NIST SP 800-90A generators
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package fortuna
+
+import (
+	"encoding/binary"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// drbgSecurityStrength is the security strength of the DRBGs in
+	// this package, in bytes.
+	drbgSecurityStrength = 32
+
+	// drbgMaxRequest is the maximal number of bytes returned by a
+	// single call to Generate (max_number_of_bits_per_request = 2^19).
+	drbgMaxRequest = 1 << 16
+
+	// drbgReseedInterval is the maximal number of requests between
+	// reseeds.
+	drbgReseedInterval = 1 << 48
+)
+
+// Error codes relating to the NIST SP 800-90A generators.
+var (
+	// ErrReseedRequired indicates that the reseed interval of a DRBG
+	// has been exhausted, and that the DRBG must be reseeded before
+	// more output can be generated.
+	ErrReseedRequired = errors.New("DRBG must be reseeded")
+
+	// ErrRequestTooLarge indicates that more output was requested from
+	// a single call to a Generate method than the DRBG allows.
+	ErrRequestTooLarge = errors.New("too many bytes requested")
+
+	// ErrShortInput indicates that the entropy input or nonce given to
+	// a DRBG is shorter than the security strength requires.
+	ErrShortInput = errors.New("entropy input or nonce too short")
+)
+
+// drbg is the common interface of the SP 800-90A mechanisms.
+type drbg interface {
+	Instantiate(entropy, nonce, personalization []byte) error
+	Reseed(entropy, additional []byte) error
+	Generate(out, additional []byte) error
+}
+
+// drbgFill fills dst using as many calls to d.Generate() as required.
+func drbgFill(d drbg, dst []byte) error {
+	for len(dst) > 0 {
+		n := len(dst)
+		if n > drbgMaxRequest {
+			n = drbgMaxRequest
+		}
+		err := d.Generate(dst[:n], nil)
+		if err != nil {
+			return err
+		}
+		dst = dst[n:]
+	}
+	return nil
+}
+
+// nonceCounter makes nonces unique within the running process.
+var nonceCounter uint64
+
+// newNonce returns a nonce for instantiating a DRBG, consisting of the
+// current time and a counter.
+func newNonce() []byte {
+	nonce := make([]byte, 16)
+	binary.BigEndian.PutUint64(nonce[:8], uint64(time.Now().UnixNano()))
+	binary.BigEndian.PutUint64(nonce[8:], atomic.AddUint64(&nonceCounter, 1))
+	return nonce
+}
+
+// instantiateFromSystem instantiates d using the initial seed data of
+// the system as entropy input.
+func instantiateFromSystem(d drbg, personalization []byte) error {
+	entropy, err := initialSeedData()
+	if err != nil {
+		return err
+	}
+	defer wipe(entropy)
+
+	return d.Instantiate(entropy, newNonce(), personalization)
+}
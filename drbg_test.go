@@ -0,0 +1,77 @@
+// drbg_test.go - common test code for the NIST SP 800-90A generators
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package fortuna
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// drbgTestVector is a known-answer test from the NIST Cryptographic
+// Algorithm Validation Program (CAVP), file "drbgtestvectors.zip".
+// The DRBG is instantiated, optionally reseeded, and then Generate is
+// called twice; the output of the second call is compared to
+// returnedBits.
+type drbgTestVector struct {
+	entropyInput          string
+	nonce                 string
+	personalizationString string
+	entropyInputReseed    string
+	additionalInputReseed string
+	additionalInput       [2]string
+	returnedBits          string
+}
+
+func unhex(s string) []byte {
+	res, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return res
+}
+
+// testDRBGVectors runs the given known-answer tests, using a fresh
+// DRBG from newDRBG for every test vector.
+func testDRBGVectors(t *testing.T, newDRBG func() drbg, vectors []drbgTestVector) {
+	t.Helper()
+	for i, v := range vectors {
+		d := newDRBG()
+		err := d.Instantiate(unhex(v.entropyInput), unhex(v.nonce),
+			unhex(v.personalizationString))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v.entropyInputReseed != "" {
+			err = d.Reseed(unhex(v.entropyInputReseed),
+				unhex(v.additionalInputReseed))
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		out := make([]byte, len(v.returnedBits)/2)
+		for _, add := range v.additionalInput {
+			err = d.Generate(out, unhex(add))
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		if !bytes.Equal(out, unhex(v.returnedBits)) {
+			t.Errorf("%d: wrong output", i)
+		}
+	}
+}
@@ -17,44 +17,78 @@
 package fortuna
 
 import (
-	"crypto/sha256"
+	"encoding/binary"
+	"errors"
 	"time"
 )
 
 const channelBufferSize = 4
 
+// ErrTooManySources is returned when a new entropy source is requested
+// while the maximal number of sources is already active, see
+// WithMaxSources().
+var ErrTooManySources = errors.New("too many entropy sources")
+
 // addRandomEvent should be called periodically to add entropy to the
 // state of the random number generator.  Different sources of
 // randomness should use different values for the 'source' argument.
 // The .allocateSource() method can be used to allocate source
-// numbers.
+// numbers.  The source number is written to the pool in unsigned
+// varint encoding, followed by the length of the data, so that
+// sources 0, ..., 127 use the same single-byte framing as the original
+// Fortuna design.
 //
 // The value 'seq' is used to spread out entropy over the available
 // entropy pools; for each entropy source, sequence values 0, 1, 2,
 // ... should be passed in.  Finally, the argument 'data' gives the
 // randomness to add to the pool.  'data' should be at most 32 bytes
 // long; longer values should be hashed by the caller and the hash be
-// submitted instead.
-func (acc *Accumulator) addRandomEvent(source uint8, seq uint, data []byte) {
-	pool := seq % numPools
+// submitted instead.  Once the Accumulator has been closed, the data
+// is discarded and ErrClosed is returned.
+func (acc *Accumulator) addRandomEvent(source uint64, seq uint, data []byte) error {
+	var frame [binary.MaxVarintLen64 + 1]byte
+	n := binary.PutUvarint(frame[:], source)
+	frame[n] = byte(len(data))
+	n++
+
 	acc.poolMutex.Lock()
 	defer acc.poolMutex.Unlock()
+	if acc.pool == nil {
+		return ErrClosed
+	}
 
+	pool := seq % uint(len(acc.pool))
 	poolHash := acc.pool[pool]
-	poolHash.Write([]byte{source, byte(len(data))})
+	poolHash.Write(frame[:n])
 	poolHash.Write(data)
 	if pool == 0 {
-		acc.poolZeroSize += 2 + len(data)
+		acc.poolZeroSize += n + len(data)
 	}
+	return nil
 }
 
-// allocateSource allocates a new source index for an entropy source.
-func (acc *Accumulator) allocateSource() uint8 {
+// allocateSource allocates a new source number for an entropy source.
+// Source numbers are never reused, so that different sources can
+// never share a number.  If the maximal number of sources is already
+// active, ErrTooManySources is returned.  The source must be returned
+// using .releaseSource() once it is no longer used.
+func (acc *Accumulator) allocateSource() (uint64, error) {
 	acc.sourceMutex.Lock()
 	defer acc.sourceMutex.Unlock()
+	if acc.activeSources >= acc.maxSources {
+		return 0, ErrTooManySources
+	}
 	source := acc.nextSource
 	acc.nextSource++
-	return source
+	acc.activeSources++
+	return source, nil
+}
+
+// releaseSource marks one entropy source as no longer active.
+func (acc *Accumulator) releaseSource() {
+	acc.sourceMutex.Lock()
+	acc.activeSources--
+	acc.sourceMutex.Unlock()
 }
 
 // NewEntropyDataSink returns a channel through which data can be
@@ -71,40 +105,64 @@ func (acc *Accumulator) allocateSource() uint8 {
 // pools instead of the data itself.
 //
 // The channel can be closed by the caller to indicate that no more
-// entropy will be sent via this channel.
+// entropy will be sent via this channel.  This also frees the entropy
+// source for reuse, see WithMaxSources().  Values sent after the
+// Accumulator has been closed are discarded, so that senders never
+// block.  For this, the goroutine reading from the channel stays alive
+// after Close() until the channel is closed.  Callers should therefore
+// always close the channel once they are done with it, to avoid
+// leaking one goroutine per sink.  The channel is a thin layer on top
+// of a Source, see NewSource().
+//
+// NewEntropyDataSink panics if the maximal number of entropy sources
+// is already in use.  Use NewEntropyDataSinkE() to get an error
+// instead.
 func (acc *Accumulator) NewEntropyDataSink() chan<- []byte {
-	source := acc.allocateSource()
+	c, err := acc.NewEntropyDataSinkE()
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// NewEntropyDataSinkE is like NewEntropyDataSink(), but returns
+// ErrTooManySources instead of panicking if the maximal number of
+// entropy sources is already in use.
+func (acc *Accumulator) NewEntropyDataSinkE() (chan<- []byte, error) {
+	src, err := acc.NewSource("data sink")
+	if err != nil {
+		return nil, err
+	}
 
 	c := make(chan []byte, channelBufferSize)
 
 	acc.sources.Add(1)
 	go func() {
-		defer acc.sources.Done()
-		seq := uint(0)
+		defer src.Close()
 
 	loop:
 		for {
 			select {
 			case data, ok := <-c:
 				if !ok {
-					break loop
-				}
-
-				if len(data) > 32 {
-					hash := sha256.New()
-					hash.Write(data)
-					data = hash.Sum(nil)
+					acc.sources.Done()
+					return
 				}
-
-				acc.addRandomEvent(source, seq, data)
-				seq++
+				src.Add(data)
 			case <-acc.stopSources:
 				break loop
 			}
 		}
+		acc.sources.Done()
+
+		// The Accumulator has been closed.  Discard all further data,
+		// so that senders do not block, until the caller closes the
+		// channel.
+		for range c {
+		}
 	}()
 
-	return c
+	return c, nil
 }
 
 // NewEntropyTimeStampSink returns a channel through which timing data
@@ -116,36 +174,62 @@ func (acc *Accumulator) NewEntropyDataSink() chan<- []byte {
 // times of network packets or the times of key-presses by the user.
 //
 // The channel can be closed by the caller to indicate that no more
-// entropy will be sent via this channel.
+// entropy will be sent via this channel.  This also frees the entropy
+// source for reuse, see WithMaxSources().  Values sent after the
+// Accumulator has been closed are discarded, so that senders never
+// block.  For this, the goroutine reading from the channel stays alive
+// after Close() until the channel is closed.  Callers should therefore
+// always close the channel once they are done with it, to avoid
+// leaking one goroutine per sink.  The channel is a thin layer on top
+// of a Source, see NewSource().
+//
+// NewEntropyTimeStampSink panics if the maximal number of entropy
+// sources is already in use.  Use NewEntropyTimeStampSinkE() to get an
+// error instead.
 func (acc *Accumulator) NewEntropyTimeStampSink() chan<- time.Time {
-	source := acc.allocateSource()
+	c, err := acc.NewEntropyTimeStampSinkE()
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// NewEntropyTimeStampSinkE is like NewEntropyTimeStampSink(), but
+// returns ErrTooManySources instead of panicking if the maximal number
+// of entropy sources is already in use.
+func (acc *Accumulator) NewEntropyTimeStampSinkE() (chan<- time.Time, error) {
+	src, err := acc.NewSource("time stamp sink")
+	if err != nil {
+		return nil, err
+	}
 
 	c := make(chan time.Time, channelBufferSize)
 
 	acc.sources.Add(1)
 	go func() {
-		defer acc.sources.Done()
-		seq := uint(0)
-		lastRequest := time.Now()
+		defer src.Close()
 
 	loop:
 		for {
 			select {
 			case now, ok := <-c:
 				if !ok {
-					break loop
+					acc.sources.Done()
+					return
 				}
-
-				dt := now.Sub(lastRequest)
-				lastRequest = now
-
-				acc.addRandomEvent(source, seq, int64ToBytes(int64(dt)))
-				seq++
+				src.AddTime(now)
 			case <-acc.stopSources:
 				break loop
 			}
 		}
+		acc.sources.Done()
+
+		// The Accumulator has been closed.  Discard all further data,
+		// so that senders do not block, until the caller closes the
+		// channel.
+		for range c {
+		}
 	}()
 
-	return c
+	return c, nil
 }
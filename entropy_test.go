@@ -17,8 +17,12 @@
 package fortuna
 
 import (
+	"bytes"
+	"errors"
 	"testing"
 	"time"
+
+	"github.com/seehuhn/sha256d"
 )
 
 func TestPoolSelection(t *testing.T) {
@@ -40,7 +44,10 @@ func TestPoolSelection(t *testing.T) {
 
 func BenchmarkAddRandomEvent(b *testing.B) {
 	acc, _ := NewRNG("")
-	source := acc.allocateSource()
+	source, err := acc.allocateSource()
+	if err != nil {
+		b.Fatal(err)
+	}
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
@@ -67,3 +74,131 @@ func BenchmarkTimeStampSink(b *testing.B) {
 		sink <- time.Now()
 	}
 }
+
+func TestSourceNumbering(t *testing.T) {
+	// An attacker who allocates 255 fake sources between two real
+	// sources must neither be able to make the real sources share a
+	// number, nor to exceed the source limit.
+	acc, err := NewAccumulatorWithOptions(WithMaxSources(1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer acc.Close()
+
+	first, err := acc.allocateSource()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 255; i++ {
+		_, err := acc.allocateSource()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	second, err := acc.allocateSource()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first == second {
+		t.Fatalf("source number %d allocated twice", first)
+	}
+
+	// Events from the two sources must lead to different pool states.
+	acc.addRandomEvent(first, 0, []byte{1, 2, 3})
+	h1 := acc.pool[0].Sum(nil)
+	acc.pool[0].Reset()
+	acc.addRandomEvent(second, 0, []byte{1, 2, 3})
+	h2 := acc.pool[0].Sum(nil)
+	if bytes.Equal(h1, h2) {
+		t.Error("events from different sources are indistinguishable")
+	}
+
+	// With the default limit, the fake sources use up all slots.
+	acc2, err := NewAccumulatorWithOptions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer acc2.Close()
+	for i := 0; i < maxSources; i++ {
+		_, err := acc2.allocateSource()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err = acc2.allocateSource()
+	if !errors.Is(err, ErrTooManySources) {
+		t.Errorf("source limit not enforced: %v", err)
+	}
+}
+
+func TestSourceLimit(t *testing.T) {
+	acc, err := NewAccumulatorWithOptions(WithMaxSources(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer acc.Close()
+
+	sink, err := acc.NewEntropyDataSinkE()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = acc.NewEntropyTimeStampSinkE()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = acc.NewEntropyDataSinkE()
+	if !errors.Is(err, ErrTooManySources) {
+		t.Errorf("source limit not enforced: %v", err)
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("NewEntropyTimeStampSink did not panic")
+			}
+		}()
+		acc.NewEntropyTimeStampSink()
+	}()
+
+	// closing a sink frees its slot
+	close(sink)
+	for i := 0; ; i++ {
+		_, err = acc.NewEntropyDataSinkE()
+		if err == nil {
+			break
+		} else if i >= 100 {
+			t.Fatal("source not released after closing the sink")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSourceFraming(t *testing.T) {
+	// Sources 0, ..., 127 keep the single byte framing of the original
+	// design, larger numbers use the unsigned varint encoding.
+	cases := []struct {
+		source uint64
+		frame  []byte
+	}{
+		{0, []byte{0, 3}},
+		{127, []byte{127, 3}},
+		{128, []byte{0x80, 0x01, 3}},
+		{256, []byte{0x80, 0x02, 3}},
+	}
+	for _, c := range cases {
+		acc, err := NewAccumulatorWithOptions(WithNumPools(1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		acc.addRandomEvent(c.source, 0, []byte{1, 2, 3})
+		h := sha256d.New()
+		h.Write(c.frame)
+		h.Write([]byte{1, 2, 3})
+		if !bytes.Equal(acc.pool[0].Sum(nil), h.Sum(nil)) {
+			t.Errorf("%d: wrong event framing", c.source)
+		}
+		if acc.poolZeroSize != len(c.frame)+3 {
+			t.Errorf("%d: wrong pool size %d", c.source, acc.poolZeroSize)
+		}
+		acc.Close()
+	}
+}
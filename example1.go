@@ -19,6 +19,7 @@
 package main
 
 import (
+	"context"
 	"crypto/rand"
 	"fmt"
 	"io"
@@ -32,6 +33,20 @@ import (
 
 const seedFileName = "seed.dat"
 
+// randCollector is an entropy collector which reads a few bytes from
+// crypto/rand.
+type randCollector struct{}
+
+func (randCollector) Collect(ctx context.Context) ([]byte, error) {
+	buffer := make([]byte, 4)
+	_, err := rand.Read(buffer)
+	return buffer, err
+}
+
+func (randCollector) Interval() time.Duration {
+	return time.Minute
+}
+
 func main() {
 	rng, err := fortuna.NewRNG(seedFileName)
 	if err != nil {
@@ -40,17 +55,14 @@ func main() {
 	defer rng.Close()
 
 	// entropy source 1: submit some randomness from crypto/rand once a minute
-	go func() {
-		sink1 := rng.NewEntropyDataSink()
-		for _ = range time.Tick(time.Minute) {
-			buffer := make([]byte, 4)
-			n, _ := rand.Read(buffer)
-			sink1 <- buffer[:n]
-		}
-	}()
+	err = rng.AddCollector(randCollector{})
+	if err != nil {
+		panic("cannot add the entropy collector: " + err.Error())
+	}
 
 	// entropy source 2: submit time between requests
 	sink2 := rng.NewEntropyTimeStampSink()
+	defer close(sink2)
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		sink2 <- time.Now()
 
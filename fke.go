@@ -0,0 +1,119 @@
+// fke.go - fast-key-erasure mode for the Fortuna generator
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package fortuna
+
+// fkeBlocks is the number of cipher blocks generated at a time in
+// fast-key-erasure mode.  The first bytes of every batch become the
+// new key, the remaining bytes are buffered for later requests.
+const fkeBlocks = 32
+
+// FastKeyErasure switches the generator to fast-key-erasure mode, as
+// described by D. J. Bernstein in "Fast-key-erasure random-number
+// generators" (2017).  In this mode, the generator produces batches of
+// 32 cipher blocks.  The first bytes of each batch immediately replace
+// the key, and the remaining bytes are kept in a buffer from which
+// subsequent requests are served.  Every byte is wiped from the buffer
+// as soon as it has been handed out.  Since the key used to generate
+// the buffer has already been replaced, knowledge of the generator
+// state still does not allow to reconstruct previous output.
+//
+// Small requests, like the ones made by Int63(), are much cheaper in
+// this mode, since most of them are served from the buffer without
+// scheduling a new key.  Large requests are generated directly into
+// the caller's buffer, followed by a rekeying, as in the default mode.
+//
+// The output of a generator in fast-key-erasure mode differs from the
+// output in the default mode.  Reseeding discards the buffer.
+func FastKeyErasure() GeneratorOption {
+	return func(gen *Generator) {
+		gen.fastKeyErasure = true
+	}
+}
+
+// discardBuffer wipes the output buffer used in fast-key-erasure mode.
+func (gen *Generator) discardBuffer() {
+	wipe(gen.fkeBuf)
+	gen.fkePos = len(gen.fkeBuf)
+}
+
+// refill generates a new batch of output in fast-key-erasure mode,
+// replaces the key with the start of the batch and keeps the rest in
+// the buffer.
+func (gen *Generator) refill() error {
+	err := gen.generateBlocks(gen.fkeBuf)
+	if err == nil {
+		err = gen.trySetKey(gen.fkeBuf[:gen.keyLen])
+	}
+	if err != nil {
+		gen.discardBuffer()
+		return err
+	}
+	wipe(gen.fkeBuf[:gen.keyLen])
+	gen.fkePos = gen.keyLen
+	return nil
+}
+
+// fillFKE implements fill() for fast-key-erasure mode.
+func (gen *Generator) fillFKE(dst []byte) error {
+	bs := len(gen.counter)
+	chunkSize := gen.rekeyBlocks * bs
+	for len(dst) > 0 {
+		if gen.fkePos == len(gen.fkeBuf) {
+			if gen.useParallel(len(dst)) {
+				n := len(dst) - len(dst)%chunkSize
+				err := gen.fillParallel(dst[:n])
+				if err != nil {
+					return err
+				}
+				dst = dst[n:]
+				continue
+			}
+			if len(dst) >= len(gen.fkeBuf) {
+				// Large requests bypass the buffer.
+				n := len(dst)
+				if n > chunkSize {
+					n = chunkSize
+				}
+				n -= n % bs
+				err := gen.generateBlocks(dst[:n])
+				if err != nil {
+					wipe(dst)
+					return err
+				}
+				dst = dst[n:]
+				err = gen.rekey()
+				if err != nil {
+					return err
+				}
+				continue
+			}
+
+			err := gen.refill()
+			if err != nil {
+				wipe(dst)
+				return err
+			}
+		}
+
+		buf := gen.fkeBuf[gen.fkePos:]
+		n := copy(dst, buf)
+		wipe(buf[:n])
+		gen.fkePos += n
+		dst = dst[n:]
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+// fke_test.go - unit tests for fke.go
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package fortuna
+
+import (
+	"bytes"
+	"crypto/aes"
+	"testing"
+)
+
+func TestFastKeyErasure(t *testing.T) {
+	gen := NewGenerator(aes.NewCipher)
+	gen.Seed(1)
+	plain := gen.PseudoRandomData(1000)
+
+	a := NewGenerator(aes.NewCipher, FastKeyErasure())
+	a.Seed(1)
+	b := NewGenerator(aes.NewCipher, FastKeyErasure())
+	b.Seed(1)
+
+	// small requests are served from the buffer, so the way the output
+	// is split into requests does not matter
+	var x, y []byte
+	for i := 0; i < 125; i++ {
+		x = append(x, a.PseudoRandomData(8)...)
+	}
+	for i := 0; i < 25; i++ {
+		y = append(y, b.PseudoRandomData(40)...)
+	}
+	if !bytes.Equal(x, y) {
+		t.Error("output depends on request sizes")
+	}
+	if bytes.Equal(x, plain) {
+		t.Error("fast-key-erasure output equals default output")
+	}
+
+	// handed out bytes are wiped from the buffer
+	if a.fkePos <= a.keyLen || !isZero(a.fkeBuf[:a.fkePos]) {
+		t.Error("used output not wiped")
+	}
+	if isZero(a.fkeBuf[a.fkePos:]) {
+		t.Error("buffer unexpectedly empty")
+	}
+
+	// large requests bypass the buffer, apart from the final partial
+	// block
+	buffered := len(a.fkeBuf) - a.fkePos
+	large := a.PseudoRandomData(uint(buffered) + 100000 + 5)
+	if a.fkePos != a.keyLen+5 || !isZero(a.fkeBuf[:a.fkePos]) {
+		t.Error("wrong buffer use for large request")
+	}
+	if isZero(large[len(large)-16:]) {
+		t.Error("large request not filled")
+	}
+
+	// reseeding discards the buffer
+	a.PseudoRandomData(1)
+	a.Reseed([]byte("seed"))
+	if a.fkePos != len(a.fkeBuf) || !isZero(a.fkeBuf) {
+		t.Error("buffer not discarded after reseeding")
+	}
+}
+
+func TestFastKeyErasureAllocs(t *testing.T) {
+	gen := NewGenerator(aes.NewCipher, FastKeyErasure())
+	gen.Seed(1)
+	allocs := testing.AllocsPerRun(100, func() {
+		gen.Int63()
+	})
+	if allocs != 0 {
+		t.Errorf("Int63 made %g allocations", allocs)
+	}
+}
+
+func TestFastKeyErasureState(t *testing.T) {
+	gen := NewGenerator(aes.NewCipher, FastKeyErasure())
+	gen.Seed(2)
+	gen.PseudoRandomData(100)
+
+	state, err := gen.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state[len(stateMagic)] != stateVersionFKE {
+		t.Error("wrong state version")
+	}
+
+	restored := NewGenerator(aes.NewCipher)
+	err = restored.UnmarshalBinary(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !restored.fastKeyErasure {
+		t.Error("mode not restored")
+	}
+	for _, n := range []uint{10, 1000, 10} {
+		if !bytes.Equal(gen.PseudoRandomData(n), restored.PseudoRandomData(n)) {
+			t.Errorf("restored generator differs for n = %d", n)
+		}
+	}
+
+	// a default mode state switches the generator back
+	plain := NewGenerator(aes.NewCipher)
+	state, err = plain.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = restored.UnmarshalBinary(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.fastKeyErasure {
+		t.Error("mode not restored")
+	}
+}
+
+func generatorInt63(b *testing.B, opts ...GeneratorOption) {
+	gen := NewGenerator(aes.NewCipher, opts...)
+	gen.Seed(0)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		gen.Int63()
+	}
+}
+
+func BenchmarkGeneratorInt63(b *testing.B)    { generatorInt63(b) }
+func BenchmarkGeneratorInt63FKE(b *testing.B) { generatorInt63(b, FastKeyErasure()) }
@@ -20,6 +20,9 @@ import (
 	"bytes"
 	"crypto/cipher"
 	"crypto/rand"
+	"errors"
+	"fmt"
+	"hash"
 	"io"
 	"io/ioutil"
 	"net"
@@ -31,11 +34,50 @@ import (
 
 const (
 	// maxBlocks gives the maximal number of blocks to generate until
-	// rekeying is required.
+	// rekeying is required, for ciphers with a block size of 128 bits
+	// or more.  See rekeyLimit() for smaller block sizes.
 	maxBlocks = 1 << 16
 
-	// keySize gives the size of the internal key in bytes
+	// keySize gives the size of the internal key in bytes, for ciphers
+	// which accept 256 bit keys.  See keySizes for the alternatives.
 	keySize = sha256d.Size
+
+	// minBlockSize is the smallest block size, in bytes, which is
+	// accepted without the AllowSmallBlocks() option.
+	minBlockSize = 16
+
+	// minSmallBlockSize is the smallest block size, in bytes, which is
+	// accepted at all.
+	minSmallBlockSize = 8
+)
+
+// keySizes lists the key sizes, in bytes and in order of preference,
+// which a Generator tries when it probes the block cipher.
+var keySizes = []int{keySize, 24, 16}
+
+// Error codes returned by the error-reporting methods of Generator.
+var (
+	// ErrNotSeeded indicates that random data was requested from a
+	// Generator before a seed was set.
+	ErrNotSeeded = errors.New("generator not yet seeded")
+
+	// ErrCipherInit indicates that the block cipher could not be
+	// initialised with a new key.  Errors of this kind wrap the error
+	// returned by the NewCipher function, if any.
+	ErrCipherInit = errors.New("cannot initialise the block cipher")
+
+	// ErrNoEntropy indicates that not enough randomness could be
+	// obtained from the operating system to seed a new Generator.
+	ErrNoEntropy = errors.New("failed to get initial randomness for the seed")
+
+	// ErrHashSize indicates that the hash function used for reseeding
+	// produces less output than the block cipher needs as a key.
+	ErrHashSize = errors.New("hash output too short for the cipher key")
+
+	// ErrWeakCipher indicates that the block size of a cipher is too
+	// small to be used safely by a Generator.  Ciphers with 64 bit
+	// blocks can be used after opting in via AllowSmallBlocks().
+	ErrWeakCipher = errors.New("block size of the cipher is too small")
 )
 
 // NewCipher is the type which represents the function to allocate a
@@ -49,6 +91,11 @@ type NewCipher func([]byte) (cipher.Block, error)
 // extracted using the PseudoRandomData() method.  The Generator class
 // implements the rand.Source interface.
 //
+// Methods like PseudoRandomData() and Reseed() panic if the generator
+// is used incorrectly or if the block cipher fails.  The methods
+// Fill() and ReseedE() provide the same functionality, but return an
+// error instead.
+//
 // This Generator class is not safe for use with concurrent accesss.
 // If the generator is accessed from different Go-routines, the
 // callers must synchronise access using sync.Mutex or similar.
@@ -57,11 +104,47 @@ type Generator struct {
 	key       []byte
 	cipher    cipher.Block
 	counter   []byte
+
+	// properties of the block cipher, see tryReset()
+	keyLen           int
+	rekeyBlocks      int
+	allowSmallBlocks bool
+	newHash          func() hash.Hash
+
+	// output buffer for fast-key-erasure mode, see FastKeyErasure()
+	fastKeyErasure bool
+	fkeBuf         []byte
+	fkePos         int
+
+	// number of goroutines for large requests, see Parallel()
+	workers int
+
+	// locked memory for the secret state, see SecureMemory()
+	secureMem bool
+	mem       *secureRegion
+
+	// scratch space, to avoid allocations when generating output
+	block  []byte
+	keyBuf []byte
+	intBuf [8]byte
+
+	// state of the continuous health test, see EnableHealthTest()
+	healthTest bool
+	prevBlock  []byte
+	havePrev   bool
+	healthErr  error
+
+	// set by Destroy()
+	destroyed bool
 }
 
 func (gen *Generator) inc() {
-	// The counter is stored least-significant byte first.
-	ctr := gen.counter
+	incCounter(gen.counter)
+}
+
+// incCounter increments a counter block.  The counter is stored
+// least-significant byte first.
+func incCounter(ctr []byte) {
 	for i := 0; i < len(ctr); i++ {
 		ctr[i]++
 		if ctr[i] != 0 {
@@ -70,27 +153,57 @@ func (gen *Generator) inc() {
 	}
 }
 
-func (gen *Generator) setKey(key []byte) {
-	if len(key) != keySize {
-		panic("wrong key size")
+// addCounter adds n to a counter block, stored least-significant byte
+// first.
+func addCounter(ctr []byte, n uint64) {
+	for i := 0; i < len(ctr) && n != 0; i++ {
+		n += uint64(ctr[i])
+		ctr[i] = byte(n)
+		n >>= 8
+	}
+}
+
+// trySetKey replaces the key of the generator.  If the block cipher
+// cannot be initialised with the new key, an error wrapping
+// ErrCipherInit is returned and the generator state is left
+// unchanged.
+func (gen *Generator) trySetKey(key []byte) error {
+	if len(key) != gen.keyLen {
+		return fmt.Errorf("%w: wrong key size %d", ErrCipherInit, len(key))
 	}
-	gen.key = key
-	cipher, err := gen.newCipher(gen.key)
+	cipher, err := gen.newCipher(key)
 	if err != nil {
-		panic("newCipher() failed, cannot set generator key")
+		return fmt.Errorf("%w: %v", ErrCipherInit, err)
 	}
+	if len(gen.key) != gen.keyLen {
+		wipe(gen.key)
+		gen.key = gen.newSecret(gen.keyLen)
+	}
+	copy(gen.key, key)
 	gen.cipher = cipher
+	return nil
 }
 
-// setInitialSeed sets the initial seed for the Generator.  An
-// attempt is made to obtain seeds which differ between machines and
+func (gen *Generator) setKey(key []byte) {
+	err := gen.trySetKey(key)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// initialSeedData collects data for the initial seed of a generator.
+// An attempt is made to obtain seeds which differ between machines and
 // between reboots.  To achieve this, the following information is
 // incorporated into the seed: the current time of day, account
 // information for the current user, and information about the
 // installed network interfaces.  In addition, if available, random
 // bytes from the random number generator in the crypto/rand package
 // are used.
-func (gen *Generator) setInitialSeed() {
+//
+// If not enough randomness can be obtained from the operating system,
+// ErrNoEntropy is returned.  The caller should wipe the returned data
+// after use.
+func initialSeedData() ([]byte, error) {
 	seedData := &bytes.Buffer{}
 	isGood := false
 
@@ -109,7 +222,8 @@ func (gen *Generator) setInitialSeed() {
 	}
 
 	if !isGood {
-		panic("failed to get initial randomness for the seed")
+		wipe(seedData.Bytes())
+		return nil, ErrNoEntropy
 	}
 
 	// source 3: current time of day (different between different runs
@@ -136,9 +250,19 @@ func (gen *Generator) setInitialSeed() {
 		seedData.Write([]byte(user.HomeDir))
 	}
 
-	buf := seedData.Bytes()
-	gen.Reseed(buf)
+	return seedData.Bytes(), nil
+}
+
+// setInitialSeed seeds the Generator using the data returned by
+// initialSeedData().
+func (gen *Generator) setInitialSeed() error {
+	buf, err := initialSeedData()
+	if err != nil {
+		return err
+	}
+	err = gen.ReseedE(buf)
 	wipe(buf)
+	return err
 }
 
 // NewGenerator creates a new instance of the Fortuna pseudo random
@@ -146,27 +270,170 @@ func (gen *Generator) setInitialSeed() {
 // aes.NewCipher from the crypto/aes package, but the Serpent or
 // Twofish ciphers can also be used.
 //
+// The generator uses 256 bit keys if the cipher accepts them, and
+// otherwise falls back to 192 or 128 bit keys.  Ciphers with a block
+// size of less than 128 bits are rejected, unless the AllowSmallBlocks()
+// option is given; for such ciphers, the generator is rekeyed more
+// frequently.
+//
 // The initial seed is chosen based on the current time, the current
 // user name, the currently installed network interfaces and
 // randomness from the system random number generator.
-func NewGenerator(newCipher NewCipher) *Generator {
+//
+// NewGenerator panics if the block cipher cannot be initialised, if
+// no initial seed can be obtained, or if the power-on self test (see
+// SelfTest()) fails.  Use NewGeneratorE() to get an error instead.
+func NewGenerator(newCipher NewCipher, opts ...GeneratorOption) *Generator {
+	gen, err := NewGeneratorE(newCipher, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return gen
+}
+
+// NewGeneratorE is like NewGenerator(), but reports failures as an
+// error instead of panicking.  If the block cipher cannot be
+// initialised, an error wrapping ErrCipherInit is returned.  If the
+// block size of the cipher is too small, an error wrapping
+// ErrWeakCipher is returned.  If no initial seed can be obtained,
+// ErrNoEntropy is returned.  If the power-on self test fails, an
+// error wrapping ErrSelfTest is returned.
+func NewGeneratorE(newCipher NewCipher, opts ...GeneratorOption) (*Generator, error) {
+	if newCipher == nil {
+		return nil, fmt.Errorf("%w: no block cipher given", ErrCipherInit)
+	}
+	err := powerOnSelfTest()
+	if err != nil {
+		return nil, err
+	}
 	gen := &Generator{
 		newCipher: newCipher,
 	}
-	gen.reset()
-	gen.setInitialSeed()
+	for _, opt := range opts {
+		opt(gen)
+	}
+	err = gen.tryReset()
+	if err != nil {
+		return nil, err
+	}
+	err = gen.setInitialSeed()
+	if err != nil {
+		return nil, err
+	}
 
-	return gen
+	return gen, nil
 }
 
-// reset reverts the generator to the unseeded state.  A new seed must
-// be set using the .Reseed() or .Seed() methods before the generator
-// can be used again.  This is mostly useful for unit testing, to
-// start the PRNG from a known state.
+// tryReset reverts the generator to the unseeded state.  A new seed
+// must be set using the .Reseed() or .Seed() methods before the
+// generator can be used again.  If the block cipher cannot be
+// initialised, an error wrapping ErrCipherInit is returned.  If the
+// block size of the cipher is too small, an error wrapping
+// ErrWeakCipher is returned.  If the hash function used for reseeding
+// produces less output than the cipher needs as a key, an error
+// wrapping ErrHashSize is returned.
+//
+// On the first call, the key sizes from keySizes are tried in turn,
+// and the first one the cipher accepts is used from then on.
+func (gen *Generator) tryReset() error {
+	if gen.destroyed {
+		return ErrDestroyed
+	}
+	if gen.secureMem {
+		// All buffers are allocated afresh below.
+		if gen.mem == nil {
+			gen.mem = newSecureRegion(secureRegionSize)
+			// On failure, fall back to ordinary memory for good.
+			gen.secureMem = gen.mem != nil
+		} else {
+			gen.mem.reset()
+		}
+		gen.key = nil
+		gen.counter = nil
+		gen.block = nil
+		gen.keyBuf = nil
+		gen.fkeBuf = nil
+		gen.prevBlock = nil
+	}
+
+	var err error
+	if gen.keyLen == 0 {
+		for _, n := range keySizes {
+			gen.keyLen = n
+			err = gen.trySetKey(make([]byte, n))
+			if err == nil {
+				break
+			}
+		}
+		if err != nil {
+			gen.keyLen = 0
+		}
+	} else {
+		err = gen.trySetKey(make([]byte, gen.keyLen))
+	}
+	if err != nil {
+		return err
+	}
+
+	if gen.newHash == nil {
+		gen.newHash = sha256d.New
+	}
+	if n := gen.newHash().Size(); n < gen.keyLen {
+		return fmt.Errorf("%w: %d byte hash, %d byte key",
+			ErrHashSize, n, gen.keyLen)
+	}
+
+	blockSize := gen.cipher.BlockSize()
+	if blockSize < minSmallBlockSize ||
+		blockSize < minBlockSize && !gen.allowSmallBlocks {
+		return fmt.Errorf("%w: %d bit blocks", ErrWeakCipher, 8*blockSize)
+	}
+	gen.rekeyBlocks = rekeyLimit(blockSize)
+	gen.counter = gen.newSecret(blockSize)
+	gen.block = gen.newSecret(blockSize)
+	gen.keyBuf = gen.newSecret(int(gen.numBlocks(uint(gen.keyLen))) * blockSize)
+	if gen.healthTest {
+		wipe(gen.prevBlock)
+		gen.prevBlock = gen.newSecret(blockSize)
+		gen.havePrev = false
+	}
+	if gen.fastKeyErasure {
+		wipe(gen.fkeBuf)
+		gen.fkeBuf = gen.newSecret(fkeBlocks * blockSize)
+		gen.fkePos = len(gen.fkeBuf)
+	}
+	return nil
+}
+
+// newUnseeded allocates a new, unseeded Generator which uses the same
+// block cipher and the same settings as gen.
+func (gen *Generator) newUnseeded() (*Generator, error) {
+	res := &Generator{
+		newCipher:        gen.newCipher,
+		keyLen:           gen.keyLen,
+		allowSmallBlocks: gen.allowSmallBlocks,
+		newHash:          gen.newHash,
+		fastKeyErasure:   gen.fastKeyErasure,
+		workers:          gen.workers,
+		secureMem:        gen.secureMem,
+	}
+	err := res.tryReset()
+	if err != nil {
+		return nil, err
+	}
+	if gen.healthTest {
+		res.EnableHealthTest()
+	}
+	return res, nil
+}
+
+// reset is like tryReset, but panics on errors.  This is mostly useful
+// for unit testing, to start the PRNG from a known state.
 func (gen *Generator) reset() {
-	zeroKey := make([]byte, keySize)
-	gen.setKey(zeroKey)
-	gen.counter = make([]byte, gen.cipher.BlockSize())
+	err := gen.tryReset()
+	if err != nil {
+		panic(err)
+	}
 }
 
 // Reseed uses the current generator state and the given seed value to
@@ -176,12 +443,38 @@ func (gen *Generator) reset() {
 //
 // This is like the ReseedInt64() method, but the seed is given as a
 // byte slice instead of as an int64.
+//
+// Reseed panics if the block cipher cannot be initialised with the
+// new key.  Use ReseedE() to get an error instead.
 func (gen *Generator) Reseed(seed []byte) {
-	hash := sha256d.New()
+	err := gen.ReseedE(seed)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// ReseedE is like Reseed(), but reports failures as an error instead
+// of panicking.  If the block cipher cannot be initialised with the
+// new key, an error wrapping ErrCipherInit is returned and the
+// generator state is left unchanged.  The new key is computed by
+// hashing the old key together with the seed, using SHA-256d or the
+// hash function set using ReseedHash().
+func (gen *Generator) ReseedE(seed []byte) error {
+	if gen.destroyed {
+		return ErrDestroyed
+	}
+	hash := gen.newHash()
 	hash.Write(gen.key)
 	hash.Write(seed)
-	gen.setKey(hash.Sum(nil))
+	key := hash.Sum(nil)
+	err := gen.trySetKey(key[:gen.keyLen])
+	wipe(key)
+	if err != nil {
+		return err
+	}
 	gen.inc()
+	gen.discardBuffer()
+	return nil
 }
 
 // ReseedInt64 uses the current generator state and the given seed
@@ -194,24 +487,74 @@ func (gen *Generator) Reseed(seed []byte) {
 func (gen *Generator) ReseedInt64(seed int64) {
 	bytes := int64ToBytes(seed)
 	gen.Reseed(bytes)
+	wipe(bytes)
 }
 
-// generateBlocks appends k blocks of random bits to data and returns
-// the resulting slice.  The size of a block is given by the block
-// size of the underlying cipher, i.e. 16 bytes for AES.
-func (gen *Generator) generateBlocks(data []byte, k uint) []byte {
+// generateBlocks fills dst with random bits.  For every (full or
+// partial) block of dst, the counter is incremented once.  The size of
+// a block is given by the block size of the underlying cipher,
+// i.e. 16 bytes for AES.  If the continuous health test is enabled and
+// fails, ErrHealthTest is returned and the contents of dst are
+// unspecified.
+func (gen *Generator) generateBlocks(dst []byte) error {
 	if isZero(gen.counter) {
-		panic("Fortuna generator not yet seeded")
+		panic(ErrNotSeeded)
+	}
+	if gen.healthErr != nil {
+		return gen.healthErr
 	}
 
-	buf := make([]byte, len(gen.counter))
-	for i := uint(0); i < k; i++ {
-		gen.cipher.Encrypt(buf, gen.counter)
-		data = append(data, buf...)
+	k := len(gen.counter)
+	full := len(dst) - len(dst)%k
+	gen.encryptCounters(dst[:full])
+	if gen.healthTest {
+		for i := 0; i < full; i += k {
+			err := gen.checkBlock(dst[i : i+k])
+			if err != nil {
+				return err
+			}
+		}
+	}
+	dst = dst[full:]
+	if len(dst) > 0 {
+		gen.cipher.Encrypt(gen.block, gen.counter)
 		gen.inc()
+		var err error
+		if gen.healthTest {
+			err = gen.checkBlock(gen.block)
+		}
+		copy(dst, gen.block)
+		wipe(gen.block)
+		return err
 	}
+	return nil
+}
 
-	return data
+// encryptCounters fills dst, whose length must be a multiple of the
+// block size, with the encrypted values of consecutive counter blocks.
+func (gen *Generator) encryptCounters(dst []byte) {
+	encryptCounters(gen.cipher, gen.counter, dst)
+}
+
+// encryptCounters fills dst, whose length must be a multiple of the
+// block size, with the encryptions of the counter blocks starting at
+// ctr, and advances ctr accordingly.  The counter blocks are first
+// written into dst and then encrypted in place, which keeps the inner
+// loop free of counter updates.
+//
+// The standard library's cipher.NewCTR would be several times faster
+// for AES, but it increments the counter in big-endian byte order,
+// while Fortuna stores the counter least-significant byte first.  Using
+// it would change the generator output.
+func encryptCounters(block cipher.Block, ctr []byte, dst []byte) {
+	k := len(ctr)
+	for i := 0; i < len(dst); i += k {
+		copy(dst[i:i+k], ctr)
+		incCounter(ctr)
+	}
+	for i := 0; i < len(dst); i += k {
+		block.Encrypt(dst[i:i+k], dst[i:i+k])
+	}
 }
 
 func (gen *Generator) numBlocks(n uint) uint {
@@ -219,33 +562,139 @@ func (gen *Generator) numBlocks(n uint) uint {
 	return (n + k - 1) / k
 }
 
+// rekey replaces the key with fresh output of the generator, so that
+// previous output cannot be reconstructed from the new generator
+// state.
+func (gen *Generator) rekey() error {
+	err := gen.generateBlocks(gen.keyBuf)
+	if err == nil {
+		err = gen.trySetKey(gen.keyBuf[:gen.keyLen])
+	}
+	wipe(gen.keyBuf)
+	return err
+}
+
+// fill fills dst with pseudo-random bytes and then rekeys the
+// generator.  Apart from memory allocated by the NewCipher function
+// during rekeying, this does not allocate.  In fast-key-erasure mode,
+// the work is delegated to fillFKE().
+func (gen *Generator) fill(dst []byte) error {
+	if gen.destroyed {
+		return ErrDestroyed
+	}
+	if isZero(gen.counter) {
+		return ErrNotSeeded
+	}
+	if gen.fastKeyErasure {
+		return gen.fillFKE(dst)
+	}
+
+	chunkSize := gen.rekeyBlocks * len(gen.counter)
+	for len(dst) > 0 {
+		if gen.useParallel(len(dst)) {
+			n := len(dst) - len(dst)%chunkSize
+			err := gen.fillParallel(dst[:n])
+			if err != nil {
+				return err
+			}
+			dst = dst[n:]
+			continue
+		}
+
+		n := len(dst)
+		if n > chunkSize {
+			n = chunkSize
+		}
+		err := gen.generateBlocks(dst[:n])
+		if err != nil {
+			// Don't hand out the output which failed the health test.
+			wipe(dst)
+			return err
+		}
+		dst = dst[n:]
+
+		err = gen.rekey()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // PseudoRandomData returns a slice of n pseudo-random bytes.  The
 // result can be used as a replacement for a sequence of n uniformly
 // distributed and independent bytes.
+//
+// PseudoRandomData panics if the generator has not been seeded or if
+// the block cipher cannot be rekeyed.  Use Fill() to get an error
+// instead.
 func (gen *Generator) PseudoRandomData(n uint) []byte {
-	numBlocks := gen.numBlocks(n)
-	res := make([]byte, 0, numBlocks*uint(len(gen.counter)))
+	res := make([]byte, n)
+	gen.FillBytes(res)
+	return res
+}
 
-	for numBlocks > 0 {
-		count := numBlocks
-		if count > maxBlocks {
-			count = maxBlocks
-		}
-		res = gen.generateBlocks(res, count)
-		numBlocks -= count
+// FillBytes fills dst with pseudo-random bytes.  The generated bytes
+// are the same as the ones PseudoRandomData(len(dst)) would return,
+// but FillBytes writes directly into the caller's buffer.  FillBytes
+// does not allocate memory itself; the only allocations are the ones
+// made by the NewCipher function when the generator is rekeyed at the
+// end of the request.
+//
+// FillBytes panics if the generator has not been seeded or if the
+// block cipher cannot be rekeyed.  Use Fill() to get an error instead.
+func (gen *Generator) FillBytes(dst []byte) {
+	err := gen.fill(dst)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Fill is like FillBytes(), but reports failures as an error instead
+// of panicking.  If the generator has not been seeded, ErrNotSeeded is
+// returned.  If the block cipher cannot be rekeyed, an error wrapping
+// ErrCipherInit is returned.  If the continuous health test (see
+// EnableHealthTest()) has failed, ErrHealthTest is returned.  In case
+// of errors, the contents of dst are unspecified.
+func (gen *Generator) Fill(dst []byte) error {
+	return gen.fill(dst)
+}
 
-		newKey := gen.generateBlocks(nil, gen.numBlocks(keySize))
-		gen.setKey(newKey[:keySize])
+// Split returns a new Generator, seeded from the output of gen and
+// from the given label.  The child key is obtained by hashing fresh
+// output of gen together with the label, in the same way as Reseed()
+// hashes the key and the seed.  Since gen is advanced by each call,
+// two children never share a key, even if the same label is used
+// twice.
+//
+// The output of the children only depends on the state of gen and on
+// the sequence of labels used, so a seeded generator can be used to
+// set up reproducible, independent streams for different goroutines.
+// To ensure reproducibility, all calls to Split() must be made in a
+// fixed order, before the children are handed out to the goroutines.
+//
+// Split panics if gen has not been seeded.
+func (gen *Generator) Split(label []byte) *Generator {
+	seed := make([]byte, keySize+len(label))
+	gen.FillBytes(seed[:keySize])
+	copy(seed[keySize:], label)
+
+	child, err := gen.newUnseeded()
+	if err != nil {
+		panic(err)
 	}
+	child.Reseed(seed)
+	wipe(seed)
 
-	return res[:n]
+	return child
 }
 
 // Int63 returns a positive random integer, uniformly distributed on
 // the range 0, 1, ..., 2^63-1.  This function is part of the
 // rand.Source interface.
 func (gen *Generator) Int63() int64 {
-	bytes := gen.PseudoRandomData(8)
+	bytes := gen.intBuf[:]
+	gen.FillBytes(bytes)
 	bytes[0] &= 0x7f
 	return bytesToInt64(bytes)
 }
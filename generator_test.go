@@ -19,6 +19,8 @@ package fortuna
 import (
 	"bytes"
 	"crypto/aes"
+	"crypto/cipher"
+	"errors"
 	"math"
 	"math/rand"
 	"testing"
@@ -115,6 +117,77 @@ func TestSeed(t *testing.T) {
 	}
 }
 
+func TestSplit(t *testing.T) {
+	split := func(labels ...string) [][]byte {
+		gen := NewGenerator(aes.NewCipher)
+		gen.Seed(99)
+		var res [][]byte
+		for _, label := range labels {
+			child := gen.Split([]byte(label))
+			res = append(res, child.PseudoRandomData(32))
+		}
+		return res
+	}
+
+	a := split("a", "b", "a")
+	b := split("a", "b", "a")
+	for i := range a {
+		if !bytes.Equal(a[i], b[i]) {
+			t.Errorf("child %d not reproducible", i)
+		}
+	}
+	if bytes.Equal(a[0], a[1]) || bytes.Equal(a[0], a[2]) {
+		t.Error("sibling streams coincide")
+	}
+
+	c := split("b")
+	if bytes.Equal(a[0], c[0]) {
+		t.Error("label does not affect the child")
+	}
+}
+
+func TestSplitConcurrent(t *testing.T) {
+	const n = 8
+
+	use := func(gen *Generator) []byte {
+		var res []byte
+		for j := 0; j < 10; j++ {
+			res = append(res, gen.PseudoRandomData(100)...)
+		}
+		return res
+	}
+
+	gen := NewGenerator(aes.NewCipher)
+	gen.Seed(1)
+	var expected [n][]byte
+	for i := 0; i < n; i++ {
+		expected[i] = use(gen.Split([]byte{byte(i)}))
+	}
+
+	gen.Seed(1)
+	var children [n]*Generator
+	for i := 0; i < n; i++ {
+		children[i] = gen.Split([]byte{byte(i)})
+	}
+	var results [n][]byte
+	done := make(chan bool)
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			results[i] = use(children[i])
+			done <- true
+		}(i)
+	}
+	for i := 0; i < n; i++ {
+		<-done
+	}
+
+	for i := 0; i < n; i++ {
+		if !bytes.Equal(results[i], expected[i]) {
+			t.Errorf("output of child %d depends on scheduling", i)
+		}
+	}
+}
+
 func TestPrng(t *testing.T) {
 	rng := NewGenerator(aes.NewCipher)
 	rng.Seed(123)
@@ -135,6 +208,119 @@ func TestPrng(t *testing.T) {
 	}
 }
 
+// failingCipher returns a NewCipher function which succeeds for the
+// first n calls and fails afterwards.
+func failingCipher(n int) NewCipher {
+	return func(key []byte) (cipher.Block, error) {
+		if n <= 0 {
+			return nil, errors.New("test failure")
+		}
+		n--
+		return aes.NewCipher(key)
+	}
+}
+
+func TestNewGeneratorE(t *testing.T) {
+	for _, n := range []int{0, 1} {
+		gen, err := NewGeneratorE(failingCipher(n))
+		if !errors.Is(err, ErrCipherInit) {
+			t.Errorf("%d: cipher failure not detected: %v", n, err)
+		}
+		if gen != nil {
+			t.Errorf("%d: Generator returned together with error", n)
+		}
+	}
+
+	_, err := NewGeneratorE(nil)
+	if !errors.Is(err, ErrCipherInit) {
+		t.Errorf("missing cipher not detected: %v", err)
+	}
+
+	gen, err := NewGeneratorE(aes.NewCipher)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if isZero(gen.counter) {
+		t.Error("new generator not seeded")
+	}
+}
+
+func TestFill(t *testing.T) {
+	gen := NewGenerator(aes.NewCipher)
+
+	gen.reset()
+	buf := make([]byte, 100)
+	err := gen.Fill(buf)
+	if err != ErrNotSeeded {
+		t.Errorf("unseeded generator not detected: %v", err)
+	}
+
+	for _, n := range []int{0, 1, 16, 100, 1<<20 + 3} {
+		gen.Seed(int64(n))
+		x := gen.PseudoRandomData(uint(n))
+		gen.Seed(int64(n))
+		y := make([]byte, n)
+		err := gen.Fill(y)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(x, y) {
+			t.Errorf("Fill and PseudoRandomData disagree for n = %d", n)
+		}
+	}
+}
+
+func TestReseedE(t *testing.T) {
+	// one call for the initial reset, one for the initial seed
+	gen, err := NewGeneratorE(failingCipher(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := append([]byte{}, gen.key...)
+	counter := append([]byte{}, gen.counter...)
+
+	err = gen.ReseedE([]byte{1, 2, 3})
+	if !errors.Is(err, ErrCipherInit) {
+		t.Errorf("cipher failure not detected: %v", err)
+	}
+	if !bytes.Equal(gen.key, key) || !bytes.Equal(gen.counter, counter) {
+		t.Error("failed reseed changed the generator state")
+	}
+
+	err = gen.Fill(make([]byte, 16))
+	if !errors.Is(err, ErrCipherInit) {
+		t.Errorf("cipher failure not detected: %v", err)
+	}
+}
+
+func TestFillBytesAllocs(t *testing.T) {
+	key := make([]byte, keySize)
+	cipherAllocs := testing.AllocsPerRun(100, func() {
+		aes.NewCipher(key)
+	})
+
+	gen := NewGenerator(aes.NewCipher)
+	gen.Seed(1)
+	for _, n := range []int{1, 8, 16, 100, 1000} {
+		buf := make([]byte, n)
+		allocs := testing.AllocsPerRun(100, func() {
+			gen.FillBytes(buf)
+		})
+		if allocs > cipherAllocs {
+			t.Errorf("FillBytes(%d bytes) made %g allocations, expected %g",
+				n, allocs, cipherAllocs)
+		}
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		gen.Int63()
+	})
+	if allocs > cipherAllocs {
+		t.Errorf("Int63 made %g allocations, expected %g",
+			allocs, cipherAllocs)
+	}
+}
+
 func BenchmarkIncCounter(b *testing.B) {
 	rng := NewGenerator(aes.NewCipher)
 	b.ResetTimer()
@@ -169,5 +355,23 @@ func BenchmarkGenerator16(b *testing.B) { generator(b, 16) }
 func BenchmarkGenerator32(b *testing.B) { generator(b, 32) }
 func BenchmarkGenerator1k(b *testing.B) { generator(b, 1024) }
 
+func generatorFillBytes(b *testing.B, n int) {
+	rng := NewGenerator(aes.NewCipher)
+	rng.Seed(0)
+	buffer := make([]byte, n)
+
+	b.SetBytes(int64(n))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rng.FillBytes(buffer)
+	}
+}
+
+func BenchmarkGeneratorFillBytes16(b *testing.B) { generatorFillBytes(b, 16) }
+func BenchmarkGeneratorFillBytes32(b *testing.B) { generatorFillBytes(b, 32) }
+func BenchmarkGeneratorFillBytes1k(b *testing.B) { generatorFillBytes(b, 1024) }
+func BenchmarkGeneratorFillBytes1M(b *testing.B) { generatorFillBytes(b, 1<<20) }
+
 // compile-time test: Generator implements the rand.Source interface
 var _ rand.Source = &Generator{}
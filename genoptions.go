@@ -0,0 +1,60 @@
+// genoptions.go - configuration options for the Fortuna generator
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package fortuna
+
+import "hash"
+
+// A GeneratorOption changes one setting of a Generator allocated by
+// NewGenerator() or NewGeneratorE().  Options for the generator of an
+// Accumulator can be given using WithGeneratorOptions().
+type GeneratorOption func(*Generator)
+
+// AllowSmallBlocks allows the use of block ciphers with 64 bit blocks,
+// like Blowfish or 3DES.  Since the output of such ciphers becomes
+// distinguishable from random data much sooner, the generator is
+// rekeyed after at most 2^(n/8) blocks for a block size of n bits
+// (256 blocks for 64 bit blocks), instead of every 2^16 blocks.
+// Ciphers with blocks of less than 64 bits are never accepted.
+func AllowSmallBlocks() GeneratorOption {
+	return func(gen *Generator) {
+		gen.allowSmallBlocks = true
+	}
+}
+
+// ReseedHash sets the hash function used by Reseed() to combine the
+// old key with the seed, for example sha512.New512_256 or
+// sha3.New256.  The first bytes of the hash value are used as the new
+// key, so the hash must produce at least as many bytes as the block
+// cipher needs as a key; otherwise NewGeneratorE() returns an error
+// wrapping ErrHashSize.  The default is SHA-256d.
+func ReseedHash(newHash func() hash.Hash) GeneratorOption {
+	return func(gen *Generator) {
+		gen.newHash = newHash
+	}
+}
+
+// rekeyLimit returns the maximal number of blocks a Generator outputs
+// between two rekeyings, for the given block size in bytes.  Fortuna
+// uses 2^16 blocks for 128 bit ciphers; for smaller blocks, the limit
+// is scaled down to 2^(n/8) blocks of n bits, to keep the
+// probability of a detectable bias similarly small.
+func rekeyLimit(blockSize int) int {
+	if blockSize >= minBlockSize {
+		return maxBlocks
+	}
+	return 1 << uint(blockSize)
+}
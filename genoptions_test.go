@@ -0,0 +1,173 @@
+// genoptions_test.go - unit tests for genoptions.go
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package fortuna
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/sha1"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// aesWithKeySize returns a NewCipher function for AES, which only
+// accepts keys of length n.
+func aesWithKeySize(n int) NewCipher {
+	return func(key []byte) (cipher.Block, error) {
+		if len(key) != n {
+			return nil, fmt.Errorf("unsupported key size %d", len(key))
+		}
+		return aes.NewCipher(key)
+	}
+}
+
+// tinyBlock pretends to be a cipher with 32 bit blocks.
+type tinyBlock struct {
+	cipher.Block
+}
+
+func (tinyBlock) BlockSize() int { return 4 }
+
+func TestKeySizes(t *testing.T) {
+	for _, n := range []int{16, 24, 32} {
+		gen, err := NewGeneratorE(aesWithKeySize(n))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if gen.keyLen != n || len(gen.key) != n {
+			t.Errorf("%d: wrong key size %d", n, gen.keyLen)
+		}
+
+		gen.Reseed([]byte{1, 2, 3})
+		gen.FillBytes(make([]byte, 100))
+		child := gen.Split([]byte("child"))
+		if child.keyLen != n {
+			t.Errorf("%d: wrong key size %d for child", n, child.keyLen)
+		}
+
+		state, err := gen.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		restored := NewGenerator(aesWithKeySize(n))
+		err = restored.UnmarshalBinary(state)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(gen.PseudoRandomData(32), restored.PseudoRandomData(32)) {
+			t.Errorf("%d: restored generator differs", n)
+		}
+
+		_, err = gen.NewStream()
+		if err != nil {
+			t.Error(err)
+		}
+	}
+
+	_, err := NewGeneratorE(aesWithKeySize(7))
+	if !errors.Is(err, ErrCipherInit) {
+		t.Errorf("unusable cipher not detected: %v", err)
+	}
+}
+
+func TestSmallBlocks(t *testing.T) {
+	_, err := NewGeneratorE(des.NewTripleDESCipher)
+	if !errors.Is(err, ErrWeakCipher) {
+		t.Errorf("64 bit cipher not rejected: %v", err)
+	}
+
+	gen1, err := NewGeneratorE(des.NewTripleDESCipher, AllowSmallBlocks())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gen1.keyLen != 24 || gen1.rekeyBlocks != 256 {
+		t.Errorf("wrong settings: key size %d, rekey after %d blocks",
+			gen1.keyLen, gen1.rekeyBlocks)
+	}
+
+	// The generator must be rekeyed after every 256 blocks, so that a
+	// single request of 512 blocks gives the same output as two
+	// requests of 256 blocks each.
+	gen1.Seed(1)
+	gen2 := NewGenerator(des.NewTripleDESCipher, AllowSmallBlocks())
+	gen2.Seed(1)
+	x := gen1.PseudoRandomData(512 * 8)
+	y := append(gen2.PseudoRandomData(256*8), gen2.PseudoRandomData(256*8)...)
+	if !bytes.Equal(x, y) {
+		t.Error("wrong rekeying interval")
+	}
+
+	newTiny := func(key []byte) (cipher.Block, error) {
+		c, err := aes.NewCipher(key)
+		return tinyBlock{c}, err
+	}
+	_, err = NewGeneratorE(newTiny, AllowSmallBlocks())
+	if !errors.Is(err, ErrWeakCipher) {
+		t.Errorf("32 bit cipher not rejected: %v", err)
+	}
+}
+
+func TestAccumulatorGeneratorOptions(t *testing.T) {
+	_, err := NewAccumulatorWithOptions(WithCipher(des.NewTripleDESCipher))
+	if !errors.Is(err, ErrWeakCipher) {
+		t.Errorf("64 bit cipher not rejected: %v", err)
+	}
+
+	acc, err := NewAccumulatorWithOptions(WithCipher(des.NewTripleDESCipher),
+		WithGeneratorOptions(AllowSmallBlocks()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	acc.RandomData(100)
+	acc.Close()
+}
+
+func TestReseedHash(t *testing.T) {
+	gen, err := NewGeneratorE(aes.NewCipher, ReseedHash(sha512.New))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gen.Seed(1)
+	oldKey := append([]byte{}, gen.key...)
+	seed := []byte{1, 2, 3, 4}
+	gen.Reseed(seed)
+
+	h := sha512.New()
+	h.Write(oldKey)
+	h.Write(seed)
+	if !bytes.Equal(gen.key, h.Sum(nil)[:keySize]) {
+		t.Error("wrong key after reseed")
+	}
+
+	child := gen.Split([]byte("child"))
+	if child.newHash == nil || child.newHash().Size() != sha512.Size {
+		t.Error("hash function not inherited by child")
+	}
+
+	_, err = NewGeneratorE(aes.NewCipher, ReseedHash(sha1.New))
+	if !errors.Is(err, ErrHashSize) {
+		t.Errorf("short hash not rejected: %v", err)
+	}
+	_, err = NewGeneratorE(aesWithKeySize(16), ReseedHash(sha1.New))
+	if err != nil {
+		t.Errorf("hash rejected for 128 bit keys: %v", err)
+	}
+}
@@ -0,0 +1,233 @@
+// hashdrbg.go - the Hash_DRBG from NIST SP 800-90A
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package fortuna
+
+import (
+	"encoding/binary"
+	"hash"
+)
+
+// HashDRBG implements the Hash_DRBG mechanism from NIST Special
+// Publication 800-90A.  The hash function is chosen when the DRBG is
+// allocated; SHA-256 and SHA-512 both give a security strength of 256
+// bits.
+//
+// A new HashDRBG must be instantiated using the Instantiate() method
+// before it can be used.  The methods Instantiate(), Reseed(),
+// Generate() and Uninstantiate() correspond to the functions of the
+// same names in SP 800-90A; prediction resistance is not supported.
+//
+// Like Generator, HashDRBG is not safe for use with concurrent access.
+type HashDRBG struct {
+	newHash       func() hash.Hash
+	hash          hash.Hash
+	v             []byte
+	c             []byte
+	reseedCounter uint64
+}
+
+// NewHashDRBG allocates a new Hash_DRBG which uses the given hash
+// function, for example sha256.New or sha512.New.  The new DRBG must
+// be instantiated before use.
+func NewHashDRBG(newHash func() hash.Hash) *HashDRBG {
+	return &HashDRBG{
+		newHash: newHash,
+	}
+}
+
+// seedLen returns the seed length in bytes, as given in table 2 of
+// SP 800-90A.
+func (d *HashDRBG) seedLen() int {
+	if d.hash.Size() <= 32 {
+		return 55
+	}
+	return 111
+}
+
+// sum computes the hash of the concatenation of all arguments and
+// appends the result to out.
+func (d *HashDRBG) sum(out []byte, data ...[]byte) []byte {
+	d.hash.Reset()
+	for _, x := range data {
+		d.hash.Write(x)
+	}
+	return d.hash.Sum(out)
+}
+
+// derive implements the Hash_df function from SP 800-90A, section
+// 10.3.1.  The inputs are concatenated and seedLen() bytes are
+// returned.
+func (d *HashDRBG) derive(inputs ...[]byte) []byte {
+	seedLen := d.seedLen()
+	var prefix [5]byte
+	binary.BigEndian.PutUint32(prefix[1:], uint32(8*seedLen))
+	args := append([][]byte{prefix[:]}, inputs...)
+
+	res := make([]byte, 0, seedLen+d.hash.Size())
+	for counter := 1; len(res) < seedLen; counter++ {
+		prefix[0] = byte(counter)
+		res = d.sum(res, args...)
+	}
+	wipe(res[seedLen:cap(res)])
+	return res[:seedLen]
+}
+
+// addTo adds x to the big-endian number acc, modulo 2^(8*len(acc)).
+// x must not be longer than acc.
+func addTo(acc, x []byte) {
+	var carry uint
+	j := len(x) - 1
+	for i := len(acc) - 1; i >= 0; i-- {
+		sum := uint(acc[i]) + carry
+		if j >= 0 {
+			sum += uint(x[j])
+			j--
+		}
+		acc[i] = byte(sum)
+		carry = sum >> 8
+	}
+}
+
+// setV replaces V by Hash_df(input) and recomputes C.
+func (d *HashDRBG) setV(inputs ...[]byte) {
+	v := d.derive(inputs...)
+	c := d.derive([]byte{0x00}, v)
+	wipe(d.v)
+	wipe(d.c)
+	d.v = v
+	d.c = c
+}
+
+// Instantiate sets the initial state of the DRBG.  The entropy input
+// must be at least 32 bytes long and the nonce at least 16 bytes,
+// otherwise ErrShortInput is returned.  The personalization string is
+// optional and may be nil.  Instantiate can also be used to restart an
+// existing DRBG from a new state.
+func (d *HashDRBG) Instantiate(entropy, nonce, personalization []byte) error {
+	if len(entropy) < drbgSecurityStrength ||
+		len(nonce) < drbgSecurityStrength/2 {
+		return ErrShortInput
+	}
+
+	d.hash = d.newHash()
+	d.setV(entropy, nonce, personalization)
+	d.reseedCounter = 1
+	return nil
+}
+
+// Reseed mixes new entropy input, and optional additional input, into
+// the state of the DRBG.  The entropy input must be at least 32 bytes
+// long, otherwise ErrShortInput is returned.  If the DRBG has not been
+// instantiated, ErrNotSeeded is returned.
+func (d *HashDRBG) Reseed(entropy, additional []byte) error {
+	if d.reseedCounter == 0 {
+		return ErrNotSeeded
+	}
+	if len(entropy) < drbgSecurityStrength {
+		return ErrShortInput
+	}
+
+	d.setV([]byte{0x01}, d.v, entropy, additional)
+	d.reseedCounter = 1
+	return nil
+}
+
+// Generate fills out with pseudo random bytes.  The optional
+// additional input is mixed into the state before the output is
+// generated.  At most 65536 bytes can be requested at a time, longer
+// requests fail with ErrRequestTooLarge.  If the DRBG has not been
+// instantiated, ErrNotSeeded is returned.  If the DRBG must be
+// reseeded, ErrReseedRequired is returned.
+func (d *HashDRBG) Generate(out, additional []byte) error {
+	if d.reseedCounter == 0 {
+		return ErrNotSeeded
+	}
+	if d.reseedCounter > drbgReseedInterval {
+		return ErrReseedRequired
+	}
+	if len(out) > drbgMaxRequest {
+		return ErrRequestTooLarge
+	}
+
+	n := d.hash.Size()
+	buf := make([]byte, 0, n)
+	defer wipe(buf[:n])
+
+	if len(additional) > 0 {
+		w := d.sum(buf, []byte{0x02}, d.v, additional)
+		addTo(d.v, w)
+	}
+
+	// Hashgen
+	data := make([]byte, len(d.v))
+	defer wipe(data)
+	copy(data, d.v)
+	for len(out) > 0 {
+		w := d.sum(buf, data)
+		k := copy(out, w)
+		out = out[k:]
+		addTo(data, []byte{1})
+	}
+
+	h := d.sum(buf, []byte{0x03}, d.v)
+	addTo(d.v, h)
+	addTo(d.v, d.c)
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], d.reseedCounter)
+	addTo(d.v, counter[:])
+	d.reseedCounter++
+	return nil
+}
+
+// Uninstantiate wipes the internal state of the DRBG.  The DRBG must
+// be instantiated again before it can be used.
+func (d *HashDRBG) Uninstantiate() {
+	wipe(d.v)
+	wipe(d.c)
+	if d.hash != nil {
+		d.hash.Reset()
+	}
+	d.reseedCounter = 0
+}
+
+// ReseedE reseeds the DRBG, using seed as the entropy input and no
+// additional input.  Together with Fill(), this allows to use a
+// HashDRBG as the generator of an Accumulator.
+func (d *HashDRBG) ReseedE(seed []byte) error {
+	return d.Reseed(seed, nil)
+}
+
+// Fill fills dst with pseudo random bytes, using as many calls to
+// Generate() as required.  No additional input is used.
+func (d *HashDRBG) Fill(dst []byte) error {
+	return drbgFill(d, dst)
+}
+
+func (d *HashDRBG) reset() {
+	d.Uninstantiate()
+}
+
+// newSeededHashDRBG allocates a new Hash_DRBG and instantiates it
+// using the initial seed data of the system.
+func newSeededHashDRBG(newHash func() hash.Hash, personalization []byte) (*HashDRBG, error) {
+	d := NewHashDRBG(newHash)
+	err := instantiateFromSystem(d, personalization)
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
@@ -0,0 +1,255 @@
+// hashdrbg_test.go - unit tests for hashdrbg.go
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package fortuna
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"testing"
+)
+
+// hashDRBG256Vectors are taken from the sections "[SHA-256]" with
+// "[PredictionResistance = False]" of the CAVP files
+// no_reseed/Hash_DRBG.rsp and pr_false/Hash_DRBG.rsp.
+var hashDRBG256Vectors = []drbgTestVector{
+	{
+		entropyInput:          "a65ad0f345db4e0effe875c3a2e71f42c7129d620ff5c119a9ef55f05185e0fb",
+		nonce:                 "8581f9317517276e06e9607ddbcbcc2e",
+		personalizationString: "",
+		additionalInput:       [2]string{"", ""},
+		returnedBits:          "d3e160c35b99f340b2628264d1751060e0045da383ff57a57d73a673d2b8d80daaf6a6c35a91bb4579d73fd0c8fed111b0391306828adfed528f018121b3febdc343e797b87dbb63db1333ded9d1ece177cfa6b71fe8ab1da46624ed6415e51ccde2c7ca86e283990eeaeb91120415528b2295910281b02dd431f4c9f70427df",
+	},
+	{
+		entropyInput:          "9b6d88373841458da926cc51f83922d363f0f80f90a2f5505c04033824ef7385",
+		nonce:                 "82b21ff47bb5e1b33288b22f3856886b",
+		personalizationString: "",
+		additionalInput:       [2]string{"45d21d94ae1ea460857b50b5b240d943d42160e4c12377e0f817b79e92530bc1", "ea432e31cc94c20d66fb13d1ef42a5f62b024134fc635aa1279a6179204731ca"},
+		returnedBits:          "3d23d0fc03936766a1e1330393e8ff6211149f3d0758db038da1c833ca8e5265c2a9ff6c8e0836904c5fcd3e61b1c77d613dc6bdaf6437573a618e3e75e455338a7f9a41300da8fd2da408cf095ff7eae1686d60ce9c2f547d0515da91600201c8374b7af8a5f49a6381aaca394c65d451341a0ae1546cd57e0d9167a6b5397d",
+	},
+	{
+		entropyInput:          "dfeabab904bfe93a37bb5b1ea4a696f881ab5ab4be87ffbf2d4e8cdfaabb37fd",
+		nonce:                 "a2d458b475053a0346b57fc518849ba1",
+		personalizationString: "d15d5d9a4a3a41877b4ea98dbda5079ee393f6ab24105dbd70f5bf145772b15c",
+		additionalInput:       [2]string{"", ""},
+		returnedBits:          "86d8c63ed4a8a19f3429b4dd57ede5ca573e861712e631400645ceee763c37cf950bdcc4d9c886ead3f0f1bf46a63bf22bd2eb39b2dac61d2e8c8f29e26045b3db56b2265adc8152d4f736c09ee90364a1e265eb5e77b0c5988c8fa52717fd33b6da760e78f2a7c27065227c47ac2134b95b7dadbf96e4ea2dad78ef200e174b",
+	},
+	{
+		entropyInput:          "68c43a008fe46a823d260a9d7fa388fb9e401f0197e7e758a744b4babb3f4651",
+		nonce:                 "eb6825777856331884aaf3751b3e4006",
+		personalizationString: "23ce0d32cbf2d26467f0d62acff1a3acbaa6d2746dc3ee7aa9d32c880788afc8",
+		additionalInput:       [2]string{"a31b9f13b58d4fa2f8d8ac42b62a207ff647339a146bd8b268b33d4aff57adbd", "d34fc6504eca4b568193c75357b0d3821a48c77ff80d6dbd21c6cf045ff489cf"},
+		returnedBits:          "abb4ecbacd4e8fa943c7221aed433861c3b203232657ec4c417d021f905d911db1058ff1e11e272232482ec96bae7cb4efc135502dbe41724077077f6de79b713670c385d04644e1281c3e582e0016255abbe5f8c06d0de57160559f0c08f7fb5be3563c649966190f8d3261364447537de2c7371c6e8c308933d27145bf90ab",
+	},
+	{
+		entropyInput:          "63363377e41e86468deb0ab4a8ed683f6a134e47e014c700454e81e95358a569",
+		nonce:                 "808aa38f2a72a62359915a9f8a04ca68",
+		personalizationString: "",
+		entropyInputReseed:    "e62b8a8ee8f141b6980566e3bfe3c04903dad4ac2cdf9f2280010a6739bc83d3",
+		additionalInputReseed: "",
+		additionalInput:       [2]string{"", ""},
+		returnedBits:          "04eec63bb231df2c630a1afbe724949d005a587851e1aa795e477347c8b056621c18bddcdd8d99fc5fc2b92053d8cfacfb0bb8831205fad1ddd6c071318a6018f03b73f5ede4d4d071f9de03fd7aea105d9299b8af99aa075bdb4db9aa28c18d174b56ee2a014d098896ff2282c955a81969e069fa8ce007a180183a07dfae17",
+	},
+	{
+		entropyInput:          "9cfb7ad03be487a3b42be06e9ae44f283c2b1458cec801da2ae6532fcb56cc4c",
+		nonce:                 "a20765538e8db31295747ec922c13a69",
+		personalizationString: "",
+		entropyInputReseed:    "96bc8014f90ebdf690db0e171b59cc46c75e2e9b8e1dc699c65c03ceb2f4d7dc",
+		additionalInputReseed: "6fea0894052dab3c44d503950c7c72bd7b87de87cb81d3bb51c32a62f742286d",
+		additionalInput:       [2]string{"d3467c78563b74c13db7af36c2a964820f2a9b1b167474906508fdac9b2049a6", "5840a11cc9ebf77b963854726a826370ffdb2fc2b3d8479e1df5dcfa3dddd10b"},
+		returnedBits:          "71c1154a2a7a3552413970bf698aa02f14f8ea95e861f801f463be27868b1b14b1b4babd9eba5915a6414ab1104c8979b1918f3094925aeab0d07d2037e613b63cbd4f79d9f95c84b47ed9b77230a57515c211f48f4af6f5edb2c308b33905db308cf88f552c8912c49b34e66c026e67b302ca65b187928a1aba9a49edbfe190",
+	},
+	{
+		entropyInput:          "b87bb4de5c148d964fc0cb612d69295671780b4270fe32bf389b6f49488efe13",
+		nonce:                 "27eb37a0c695c4ee3c9b70b7f6b33492",
+		personalizationString: "52321406ac8a9c266b1f8d811bb871269e5824b59a0234f01d358193523bbb7c",
+		entropyInputReseed:    "7638267f534c4e6ee22cc6ca6ed824fd5d3d387c00b89dd791eb5ac9766385b8",
+		additionalInputReseed: "",
+		additionalInput:       [2]string{"", ""},
+		returnedBits:          "de01c061651bab3cef2fc4ea89a56b6e86e74b2e9fd11ed671c97c813778a06a2c1f41b41e754a5257750c6bde9601da9d67d8d9564f4a8538b92516a2dacc496dee257b85393f2a01ad59aa3257f1b6da9566e3706d2d6d4a26e511b0c64d7dc223acb24827178afa43ca8d5a66f983d6929dc61564c4c14fc32d85765a23f7",
+	},
+	{
+		entropyInput:          "6c623aea73bc8a59e28c6cd9c7c7ec8ca2e75190bd5dcae5978cf0c199c23f4f",
+		nonce:                 "e55db067a0ed537e66886b7cda02f772",
+		personalizationString: "1e59d798810083d1ff848e90b25c9927e3dfb55a0888b0339566a9f9ca7542dc",
+		entropyInputReseed:    "9ab40164744c7d00c78b4196f6f917ec33d70030a0812cd4606c5a25387568a9",
+		additionalInputReseed: "4e8bead7cbba7a7bc9ae1e1617222c4139661347599950e7225d1e2faa5d57f5",
+		additionalInput:       [2]string{"dcb22a5d9f149858636f3ede2253e419816fb7b1103194451ed6a573a8fe6271", "8f9d5c78cdabc32e71ac3b3c49239caddf96053250f4fd92056efbd0be487d36"},
+		returnedBits:          "6e98a3b1f686f6ffa79355c9d8a5ab7f93312159d52659a2298315f10007c71adabc0b5ccb4164c0949fbdb221b43acdb62bed3099596f2d7bd5d0048173dd2360a543b234ab61a441ddb9299af84ca45c6e618fd521366dbf509d4ec06174da924361d642b107e5564ac1b32340dd2f3158bf4c00bcb4dcf12c6d67af4b74ee",
+	},
+}
+
+// hashDRBG512Vectors are taken from the sections "[SHA-512]" with
+// "[PredictionResistance = False]" of the CAVP files
+// no_reseed/Hash_DRBG.rsp and pr_false/Hash_DRBG.rsp.
+var hashDRBG512Vectors = []drbgTestVector{
+	{
+		entropyInput:          "6b50a7d8f8a55d7a3df8bb40bcc3b722d8708de67fda010b03c4c84d72096f8c",
+		nonce:                 "3ec649cc6256d9fa31db7a2904aaf025",
+		personalizationString: "",
+		additionalInput:       [2]string{"", ""},
+		returnedBits:          "95b7f17e9802d3577392c6a9c08083b67dd1292265b5f42d237f1c55bb9b10bfcfd82c77a378b8266a0099143b3c2d64611eeeb69acdc055957c139e8b190c7a06955f2c797c2778de940396a501f40e91396acf8d7e45ebdbb53bbf8c975230d2f0ff9106c76119ae498e7fbc03d90f8e4c51627aed5c8d4263d5d2b978873a0de596ee6dc7f7c29e37eee8b34c90dd1cf6a9ddb22b4cbd086b14b35de93da2d5cb1806698cbd7bbb67bfe3d31fd2d1dbd2a1e058a3eb99d7e51f1a938eed5e1c1de23a6b4345d3191409f92f39b3670d8dbfb635d8e6a36932d81033d1448d63b403ddf88e121b6e819ac381226c1321e4b08644f6727c368c5a9f7a4b3ee2",
+	},
+	{
+		entropyInput:          "9c96a34f68689b8aa8d9c1f6cd0fa7c6f96071caf1bf5556f45bdbf48c6cf0c6",
+		nonce:                 "885c2539046afb1401eb7a5c84dbd9c2",
+		personalizationString: "",
+		additionalInput:       [2]string{"cb61c4f75c01b578aa233a0bae4881c0a11527c22fe7b34fb6ae62eebcfe6085", "c066fd2eb8e4aea2e7145eda0cfc8bef5eedcc367b1cb4de7eb2c2759fa75bf7"},
+		returnedBits:          "782c208ed58044e78b5bbbd8772a3caf25b47d36afeb0d3493c43e01cc66a0ca2faced2ab186bc46825d989cf8ee7c95f8c0b0d2b76e6c8590e72834d4c52445aeceeb7bf5f5d9ac44a12cbd3fa7f4462f856452dc4a929182d2388aa7635b9698a912585df7f560adc5080d53b82bbd7e9e480b00d1da5bb2d480cae2ba8c67d4bf3bfd146a91d6aab39faae1600af2ce3204cabf4c1caee4cfd5e6f8db1902033f7f8d33bc6e0e5d32a320ba735d091f30867b7cb7880c2e3ce6aada79664191df360d35fe9ae7babca41485b06ab49dff528782fbe6f2b0e74996e9ce9272d1ef392be5c17cc62c74be504e6a8731dd9548b0db27e0b7db4886f537883623",
+	},
+	{
+		entropyInput:          "67d492360c69fd41aca0ac52f5e2ba1820a5e73fb5fe5dbcd00bb9ea05af0523",
+		nonce:                 "5e7d69e187577b0433eee8eab9f77731",
+		personalizationString: "22e4e18124ef50ae514d5146479d83f0be23c5c4df4ba208e5e5b3506d3e104e",
+		additionalInput:       [2]string{"", ""},
+		returnedBits:          "f7aa49abb823c6c41e99e782d098821b9f4029790c701d015b356a1b7c655ff1553180e20cdda09c82864933a079cb81ef033d356ed011ad2777dcca17666127c230198c89f1f372fddb307614d062187f0b4099101617d5c2e1b4792b1d91bf5eec60ba1dbf20e7b070379c3a097a98a043a583718101c052f29dc281d1f666494e11d5f80cded4e6a9385143d0bf33e7d687f8204cc97f57d9f0ddfc205a8efe2787e8f49575ebf83fabf585840212dc6fce1c54df92608b01e7d36538d9ef2b8a6b8910daa3c8ccc72f284cc2f2573412085d232e29eabbe61b27eaa2ed485059198d0ae57bb35a7fa66492c12e782c5774e1abeb202e0744e9d766f2f133",
+	},
+	{
+		entropyInput:          "31e8d6fbdc9026b0708405c20b558fcc0a107f3fdc836fe056f020df30d9dc57",
+		nonce:                 "2b8bbab9b486abb659c4ae8ff5978e22",
+		personalizationString: "949eb753762869aa5ea0ce725523595f9bc9b219735113e71feab228d0872c38",
+		additionalInput:       [2]string{"88f1180d4ef564315280a9692f107ed9c0639d79bb7040dfc3b7d58bf24ef8f5", "f4fc8a26e0ad181838f1399fe5b8a4b86670e92ab92b2c4daf3913470724d3f2"},
+		returnedBits:          "10509641332a4d72a3c5936512c37cb9ab9874693902ee4c76e963675627ef86aa2e7d7029a152b800072fc53eeb6b41d12f481cde99b467dac3486836f6e146e9a79d3fb90d9b26f213ddbfac590ca083ed83fde4924395d25b645b96a6983e65fd662cae66112ebfa990f09b86b01270b7f0ef35f183eb01ffcbd7d5ec6adc4839cf3814dac858e013c6d79528ef273dd83724ccdc82b73dc63698fcf8ef0924f27b6a49d6d38f0ce261aa5a0a88779e47a413c29e1d7d20e4ab914bbabd5e6e0241cf53263a8efa321b4a632eb062b255c0ce5a0833114161dd073dd037967a1f03daf2dd7e927b801b40e62f26c0872ea100132807650232126aa8f29d70",
+	},
+	{
+		entropyInput:          "3144e17a10c856129764f58fd8e4231020546996c0bf6cff8e91c24ee09be333",
+		nonce:                 "b16fcb1cf0c010f31feab733588b8e04",
+		personalizationString: "",
+		entropyInputReseed:    "a0b3584c2c8412f618406834404d1eb0ce999ba28966054d7e497e0db608b967",
+		additionalInputReseed: "",
+		additionalInput:       [2]string{"", ""},
+		returnedBits:          "efa35dd0362adb7626456b36fac74d3c28d01d926420275a28bea9c9dd7547c15e7931852ac1277076567535239c1f429c7f75cf74c2267deb6a3e596cf326156c796941283b8d583f171c2f6e3323f7555e1b181ffda30507210cb1f589b23cd71880fd44370cacf43375b0db7e336f12b309bfd4f610bb8f20e1a15e253a4fe511a027968df0b105a1d73aff7c7a826d39f640dfb8f522259ed402282e2c2e9d3a498f51725fe4141b06da5598a42ac1e0494e997d566a1a39b676b96a6003a4c5db84f246584ee65af70ff2160278166da16d91c9b8f2deb02751a1088ad6be4e80ef966eb73e66bc87cad87c77c0b34a21ba1da0ba6d16ca5046dc4abda0",
+	},
+	{
+		entropyInput:          "c73a7820f0f53e8bbfc3b7b71d994143cf6e98642e9ea6d8df5dccbc43db8720",
+		nonce:                 "20cc9834b588adcb1bbde64f0d2a34cb",
+		personalizationString: "",
+		entropyInputReseed:    "12dd2aca8879046d23165c60f8aedc20415783e156d42a94346826aaeb02eacf",
+		additionalInputReseed: "9b59ff78a34eabe0060c2792ca9b49e9781e6b802badf7dbde27caaed3343706",
+		additionalInput:       [2]string{"dc74a9e480a6ff6f6bce53ab9c7bdde4b13d70fb5196cdd5e3a0555ccf06fe91", "8f3f229011209b2f399096afb054bccca6bc46aaee98845838fb1fb78b66f3bd"},
+		returnedBits:          "e6c96442582811ec90e587525f36c555e2fd6361a0c5b0284917a4fa6f6e8ace83f11a1fb26cea6692b225ae7c5be286dd27471f323d7a2e4431722bb337b1ba0e648ea2e9f0918b50e9111f2377636ba69b0e1cb5295078d76c549c8656940eb15ca5aded7adc46e6fa4b86948f212fea3f3befdeece8b20e420ca84c760196ddf0b074df0a9f097a5db8f6125800f5fe746a62df1208042f1255b524465a17efcf6a537612968430e2adcff30f7407a51ed7305334384e512e003642cca175636819f021c76a2f44e89e6fe39cf164477910379cd314f735c357f9379de22495276b401c98ffb09a6dc03e484b355a9464511401eeaa05b4556e73b55227f8",
+	},
+	{
+		entropyInput:          "83bff60214370ccb1c8f2142b528ef70e71dcf343a42f149737c43c869886901",
+		nonce:                 "b7dd677ff8891a3a6b3e63920310bd82",
+		personalizationString: "84719a3399ed20d47f5912e888623f8a0929492951d65d8b01376150f13fae1d",
+		entropyInputReseed:    "aab08d7baa18b6b79e908bd7c48ea5188577988be95c34b6aa952070db27ac4f",
+		additionalInputReseed: "",
+		additionalInput:       [2]string{"", ""},
+		returnedBits:          "ae39d5886dcb734d7eda77bcf0f9492672fe771a4a196bd18e547eff62abc3fdbd426b0690092699a28e49fcb64b036cf4a2e51321214ad742edc099bb5bac098f834d22bd6dacd006f3f9722556d335ff748378ef12c48d1c3ac223554616ec6af318b6357025792dca4ce687534918c8e8c569339fe9282174035c1a74bd453a84a2458fa58e56e265aa10573e248dacfcb0150d89c60182076111a461b5acf0201bd0f2206dc24a6c9a846f7c0773f3deed13447f4b89788e681a6fde808590cec544bc31af29d5164306bb353bc09ca6bc8c95ea14b18189cc4131457ab734fc02b6a39f2defecfcdfa5fe65b2589800edf6eef92d1399bc9281b05083f4",
+	},
+	{
+		entropyInput:          "4b23595b0a3640cfabb0ec34df6a613308b0448488a5d9ff99da4278e072eb34",
+		nonce:                 "8e696bffd9ca3a71d2e2f05e600c8364",
+		personalizationString: "010ba93ea68a3d4a200e5145859e299c5b5349b7645fb5bbcad687aba7d67313",
+		entropyInputReseed:    "04de4babdbe143bde99aa4452f9aa43b0a164eb927555c0496aa0fc9328a521c",
+		additionalInputReseed: "2b0c7c3efb36b71b917a44086d168313675b426b17c5ab3d0eb6af753f6040e0",
+		additionalInput:       [2]string{"d0b7d1d12ab15d3bba8f4eba07fee0974838962b247be480683b8e3d4a91033a", "66c78ca12e45bdca003b49cb6440b977dd85b167e7c803890ed1a73666eaa869"},
+		returnedBits:          "4008cbd8281dc82fd6c368f650ef2609bb771e80c63d478a77fa938248dcbb8b79e54ead0265f6ff1ebfafe4e387c6e27df9f03e4a5225e86a4436e56ebf03b3be2cfbcb49c89c92ec1dfa5ee445dd4f6f64e02a2423a0b18ebd02eec52f5cc21bc3565e796b3ded6552f1b5a574a201c3b11018222806f9618d23d77fd02db879cf87fe24ed7ba11b3b108b559633db1f95c5121b28011aa4dd20399bd4978e1f8b8880c333a47ff1750679bf28d329347b26d347aae90ee562ae8029579cbe0336e066d6b8ba5e0169fec804c30189a4434c1bf8a5b0a249951d3d89554da38ff0751b8b1fef9ae18a0aa2bc477736d199a06f61d400039a4cc03869bb10ca",
+	},
+}
+
+func TestHashDRBGVectors(t *testing.T) {
+	testDRBGVectors(t, func() drbg { return NewHashDRBG(sha256.New) },
+		hashDRBG256Vectors)
+	testDRBGVectors(t, func() drbg { return NewHashDRBG(sha512.New) },
+		hashDRBG512Vectors)
+}
+
+func TestHashDRBGErrors(t *testing.T) {
+	d := NewHashDRBG(sha256.New)
+	buf := make([]byte, 16)
+	if err := d.Generate(buf, nil); err != ErrNotSeeded {
+		t.Errorf("uninstantiated DRBG not detected: %v", err)
+	}
+
+	entropy := make([]byte, 32)
+	nonce := make([]byte, 16)
+	if err := d.Instantiate(entropy[:31], nonce, nil); err != ErrShortInput {
+		t.Errorf("short entropy input not detected: %v", err)
+	}
+	if err := d.Instantiate(entropy, nonce[:15], nil); err != ErrShortInput {
+		t.Errorf("short nonce not detected: %v", err)
+	}
+	if err := d.Instantiate(entropy, nonce, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Reseed(entropy[:31], nil); err != ErrShortInput {
+		t.Errorf("short entropy input not detected: %v", err)
+	}
+	if err := d.Generate(make([]byte, drbgMaxRequest+1), nil); err != ErrRequestTooLarge {
+		t.Errorf("large request not detected: %v", err)
+	}
+
+	d.reseedCounter = drbgReseedInterval + 1
+	if err := d.Generate(buf, nil); err != ErrReseedRequired {
+		t.Errorf("exhausted reseed interval not detected: %v", err)
+	}
+	if err := d.Reseed(entropy, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Generate(buf, nil); err != nil {
+		t.Error(err)
+	}
+
+	d.Uninstantiate()
+	if !isZero(d.v) || !isZero(d.c) {
+		t.Error("state not wiped")
+	}
+	if err := d.Generate(buf, nil); err != ErrNotSeeded {
+		t.Errorf("uninstantiated DRBG not detected: %v", err)
+	}
+}
+
+func TestAccumulatorHashDRBG(t *testing.T) {
+	acc, err := NewAccumulatorWithOptions(
+		WithHashDRBG(sha512.New, []byte("test")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := acc.gen.(*HashDRBG); !ok {
+		t.Fatal("wrong generator type")
+	}
+
+	for i := uint(0); i < 100; i++ {
+		acc.addRandomEvent(0, i, make([]byte, 32))
+	}
+	x := acc.RandomData(drbgMaxRequest + 100)
+	y := acc.RandomData(drbgMaxRequest + 100)
+	if bytes.Equal(x, y) {
+		t.Error("repeated output")
+	}
+
+	err = acc.Close()
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func BenchmarkHashDRBG1k(b *testing.B) {
+	d, _ := newSeededHashDRBG(sha256.New, nil)
+	buffer := make([]byte, 1024)
+
+	b.SetBytes(int64(len(buffer)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		d.Fill(buffer)
+	}
+}
+
+// compile-time test: HashDRBG implements the RandomGenerator interface
+var _ RandomGenerator = &HashDRBG{}
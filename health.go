@@ -0,0 +1,62 @@
+// health.go - continuous health test for the generator output
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package fortuna
+
+import (
+	"bytes"
+	"errors"
+)
+
+// ErrHealthTest indicates that the continuous health test of a
+// Generator has detected two identical consecutive output blocks.
+// Once this has happened, the Generator stays in a permanent error
+// state and refuses to produce any more output.
+var ErrHealthTest = errors.New("continuous health test failed")
+
+// EnableHealthTest switches on the continuous random number generator
+// test described in FIPS 140-2, section 4.9.2: every block of output
+// is compared to the previous block, and if the two blocks are equal
+// the generator enters a permanent error state.  From then on, Fill()
+// returns ErrHealthTest and methods like PseudoRandomData() panic.
+// The error state persists across calls to Reseed(), Seed() and
+// UnmarshalBinary().
+//
+// The first block generated after the health test is enabled (and
+// after every call to Seed()) is only used as the reference for the
+// comparison with the following block.
+func (gen *Generator) EnableHealthTest() {
+	if gen.healthTest {
+		return
+	}
+	gen.healthTest = true
+	gen.prevBlock = gen.newSecret(len(gen.counter))
+	gen.havePrev = false
+}
+
+// checkBlock implements the continuous health test for one block of
+// output.  The block must be a full block of generator output.
+func (gen *Generator) checkBlock(block []byte) error {
+	if gen.havePrev && bytes.Equal(block, gen.prevBlock) {
+		gen.healthErr = ErrHealthTest
+		wipe(gen.prevBlock)
+		gen.havePrev = false
+		return ErrHealthTest
+	}
+	copy(gen.prevBlock, block)
+	gen.havePrev = true
+	return nil
+}
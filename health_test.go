@@ -0,0 +1,184 @@
+// health_test.go - unit tests for health.go
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package fortuna
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// stuckCipher is a broken block cipher which always produces the same
+// output block.
+type stuckCipher struct{}
+
+func newStuckCipher(key []byte) (cipher.Block, error) {
+	return stuckCipher{}, nil
+}
+
+func (stuckCipher) BlockSize() int { return aes.BlockSize }
+
+func (stuckCipher) Encrypt(dst, src []byte) {
+	for i := 0; i < aes.BlockSize; i++ {
+		dst[i] = 0xAA
+	}
+}
+
+func (stuckCipher) Decrypt(dst, src []byte) {
+	panic("not implemented")
+}
+
+func TestHealthTest(t *testing.T) {
+	gen, err := NewGeneratorE(newStuckCipher)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// without the health test, the stuck output is not detected
+	buf := make([]byte, 40)
+	err = gen.Fill(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gen.EnableHealthTest()
+	err = gen.Fill(buf)
+	if err != ErrHealthTest {
+		t.Fatalf("stuck output not detected: %v", err)
+	}
+	if !isZero(buf) {
+		t.Error("output returned despite failed health test")
+	}
+
+	// the error state is permanent
+	gen.Reseed([]byte("new seed"))
+	if err := gen.Fill(buf); err != ErrHealthTest {
+		t.Errorf("error state cleared by Reseed: %v", err)
+	}
+	gen.Seed(1)
+	if err := gen.Fill(buf); err != ErrHealthTest {
+		t.Errorf("error state cleared by Seed: %v", err)
+	}
+}
+
+func TestHealthTestOutput(t *testing.T) {
+	// Enabling the health test must not change the generator output.
+	a := NewGenerator(aes.NewCipher)
+	a.Seed(7)
+	b := NewGenerator(aes.NewCipher)
+	b.EnableHealthTest()
+	b.Seed(7)
+
+	for _, n := range []uint{1, 16, 17, 1000, maxBlocks*16 + 5} {
+		x := a.PseudoRandomData(n)
+		y := b.PseudoRandomData(n)
+		if !bytes.Equal(x, y) {
+			t.Errorf("%d: output differs", n)
+		}
+	}
+
+	child := b.Split([]byte("child"))
+	if !child.healthTest {
+		t.Error("health test not inherited by Split")
+	}
+}
+
+func TestAccumulatorHealthTest(t *testing.T) {
+	var failures []error
+	acc, err := NewAccumulatorWithOptions(WithCipher(newStuckCipher),
+		WithHealthTest(func(err error) {
+			failures = append(failures, err)
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 64)
+	for i := 0; i < 2; i++ {
+		n, err := acc.Read(buf)
+		if n != 0 || !errors.Is(err, ErrHealthTest) {
+			t.Errorf("%d: stuck output not detected: %d, %v", i, n, err)
+		}
+	}
+	if len(failures) != 1 || !errors.Is(failures[0], ErrHealthTest) {
+		t.Errorf("wrong callbacks %v", failures)
+	}
+
+	hasPanicked := func() (res bool) {
+		defer func() {
+			if recover() != nil {
+				res = true
+			}
+		}()
+		acc.RandomData(16)
+		return false
+	}()
+	if !hasPanicked {
+		t.Error("RandomData did not panic")
+	}
+
+	acc.Close()
+}
+
+func TestHealthTestSeedFile(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+	seedFileName := filepath.Join(tempDir, "seed")
+
+	// a failure while writing the initial seed file is reported
+	_, err = NewAccumulatorWithOptions(WithCipher(newStuckCipher),
+		WithHealthTest(nil), WithSeedFile(seedFileName))
+	if !errors.Is(err, ErrHealthTest) {
+		t.Errorf("stuck output not detected: %v", err)
+	}
+
+	// a failure while writing the final seed file is reported
+	acc, err := NewAccumulatorWithOptions(WithHealthTest(nil),
+		WithSeedFile(seedFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	acc.gen.(*Generator).healthErr = ErrHealthTest
+	err = acc.Close()
+	if !errors.Is(err, ErrHealthTest) {
+		t.Errorf("wrong error from Close: %v", err)
+	}
+}
+
+func TestHealthTestInvalid(t *testing.T) {
+	for i, opt := range []Option{WithChaCha(), WithCTRDRBG(nil)} {
+		_, err := NewAccumulatorWithOptions(opt, WithHealthTest(nil))
+		if !errors.Is(err, ErrInvalidOption) {
+			t.Errorf("%d: unsupported generator not detected: %v", i, err)
+		}
+	}
+
+	acc, err := NewAccumulatorWithOptions(WithCombinedCipher(newReversedAES),
+		WithHealthTest(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	acc.Close()
+}
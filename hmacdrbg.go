@@ -0,0 +1,181 @@
+// hmacdrbg.go - the HMAC_DRBG from NIST SP 800-90A
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package fortuna
+
+import (
+	"crypto/hmac"
+	"hash"
+)
+
+// HMACDRBG implements the HMAC_DRBG mechanism from NIST Special
+// Publication 800-90A.  The hash function is chosen when the DRBG is
+// allocated; SHA-256 and SHA-512 both give a security strength of 256
+// bits.
+//
+// A new HMACDRBG must be instantiated using the Instantiate() method
+// before it can be used.  The methods Instantiate(), Reseed(),
+// Generate() and Uninstantiate() correspond to the functions of the
+// same names in SP 800-90A; prediction resistance is not supported.
+//
+// Like Generator, HMACDRBG is not safe for use with concurrent access.
+type HMACDRBG struct {
+	newHash       func() hash.Hash
+	k             []byte
+	v             []byte
+	reseedCounter uint64
+}
+
+// NewHMACDRBG allocates a new HMAC_DRBG which uses the given hash
+// function, for example sha256.New or sha512.New.  The new DRBG must
+// be instantiated before use.
+func NewHMACDRBG(newHash func() hash.Hash) *HMACDRBG {
+	return &HMACDRBG{
+		newHash: newHash,
+	}
+}
+
+// mac computes HMAC(K, data...) and stores the result in out.
+func (d *HMACDRBG) mac(out []byte, data ...[]byte) {
+	h := hmac.New(d.newHash, d.k)
+	for _, x := range data {
+		h.Write(x)
+	}
+	copy(out, h.Sum(out[:0]))
+}
+
+// update implements HMAC_DRBG_Update.  The provided data is the
+// concatenation of all arguments.
+func (d *HMACDRBG) update(provided ...[]byte) {
+	providedLen := 0
+	for _, x := range provided {
+		providedLen += len(x)
+	}
+
+	for _, sep := range []byte{0x00, 0x01} {
+		args := append([][]byte{d.v, {sep}}, provided...)
+		d.mac(d.k, args...)
+		d.mac(d.v, d.v)
+		if providedLen == 0 {
+			break
+		}
+	}
+}
+
+// Instantiate sets the initial state of the DRBG.  The entropy input
+// must be at least 32 bytes long and the nonce at least 16 bytes,
+// otherwise ErrShortInput is returned.  The personalization string is
+// optional and may be nil.  Instantiate can also be used to restart an
+// existing DRBG from a new state.
+func (d *HMACDRBG) Instantiate(entropy, nonce, personalization []byte) error {
+	if len(entropy) < drbgSecurityStrength ||
+		len(nonce) < drbgSecurityStrength/2 {
+		return ErrShortInput
+	}
+
+	n := d.newHash().Size()
+	d.k = make([]byte, n)
+	d.v = make([]byte, n)
+	for i := range d.v {
+		d.v[i] = 0x01
+	}
+	d.update(entropy, nonce, personalization)
+	d.reseedCounter = 1
+	return nil
+}
+
+// Reseed mixes new entropy input, and optional additional input, into
+// the state of the DRBG.  The entropy input must be at least 32 bytes
+// long, otherwise ErrShortInput is returned.  If the DRBG has not been
+// instantiated, ErrNotSeeded is returned.
+func (d *HMACDRBG) Reseed(entropy, additional []byte) error {
+	if d.reseedCounter == 0 {
+		return ErrNotSeeded
+	}
+	if len(entropy) < drbgSecurityStrength {
+		return ErrShortInput
+	}
+
+	d.update(entropy, additional)
+	d.reseedCounter = 1
+	return nil
+}
+
+// Generate fills out with pseudo random bytes.  The optional
+// additional input is mixed into the state before the output is
+// generated.  At most 65536 bytes can be requested at a time, longer
+// requests fail with ErrRequestTooLarge.  If the DRBG has not been
+// instantiated, ErrNotSeeded is returned.  If the DRBG must be
+// reseeded, ErrReseedRequired is returned.
+func (d *HMACDRBG) Generate(out, additional []byte) error {
+	if d.reseedCounter == 0 {
+		return ErrNotSeeded
+	}
+	if d.reseedCounter > drbgReseedInterval {
+		return ErrReseedRequired
+	}
+	if len(out) > drbgMaxRequest {
+		return ErrRequestTooLarge
+	}
+
+	if len(additional) > 0 {
+		d.update(additional)
+	}
+	for len(out) > 0 {
+		d.mac(d.v, d.v)
+		n := copy(out, d.v)
+		out = out[n:]
+	}
+	d.update(additional)
+	d.reseedCounter++
+	return nil
+}
+
+// Uninstantiate wipes the internal state of the DRBG.  The DRBG must
+// be instantiated again before it can be used.
+func (d *HMACDRBG) Uninstantiate() {
+	wipe(d.k)
+	wipe(d.v)
+	d.reseedCounter = 0
+}
+
+// ReseedE reseeds the DRBG, using seed as the entropy input and no
+// additional input.  Together with Fill(), this allows to use an
+// HMACDRBG as the generator of an Accumulator.
+func (d *HMACDRBG) ReseedE(seed []byte) error {
+	return d.Reseed(seed, nil)
+}
+
+// Fill fills dst with pseudo random bytes, using as many calls to
+// Generate() as required.  No additional input is used.
+func (d *HMACDRBG) Fill(dst []byte) error {
+	return drbgFill(d, dst)
+}
+
+func (d *HMACDRBG) reset() {
+	d.Uninstantiate()
+}
+
+// newSeededHMACDRBG allocates a new HMAC_DRBG and instantiates it
+// using the initial seed data of the system.
+func newSeededHMACDRBG(newHash func() hash.Hash, personalization []byte) (*HMACDRBG, error) {
+	d := NewHMACDRBG(newHash)
+	err := instantiateFromSystem(d, personalization)
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
@@ -0,0 +1,255 @@
+// hmacdrbg_test.go - unit tests for hmacdrbg.go
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package fortuna
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"testing"
+)
+
+// hmacDRBG256Vectors are taken from the sections "[SHA-256]" with
+// "[PredictionResistance = False]" of the CAVP files
+// no_reseed/HMAC_DRBG.rsp and pr_false/HMAC_DRBG.rsp.
+var hmacDRBG256Vectors = []drbgTestVector{
+	{
+		entropyInput:          "ca851911349384bffe89de1cbdc46e6831e44d34a4fb935ee285dd14b71a7488",
+		nonce:                 "659ba96c601dc69fc902940805ec0ca8",
+		personalizationString: "",
+		additionalInput:       [2]string{"", ""},
+		returnedBits:          "e528e9abf2dece54d47c7e75e5fe302149f817ea9fb4bee6f4199697d04d5b89d54fbb978a15b5c443c9ec21036d2460b6f73ebad0dc2aba6e624abf07745bc107694bb7547bb0995f70de25d6b29e2d3011bb19d27676c07162c8b5ccde0668961df86803482cb37ed6d5c0bb8d50cf1f50d476aa0458bdaba806f48be9dcb8",
+	},
+	{
+		entropyInput:          "d3cc4d1acf3dde0c4bd2290d262337042dc632948223d3a2eaab87da44295fbd",
+		nonce:                 "0109b0e729f457328aa18569a9224921",
+		personalizationString: "",
+		additionalInput:       [2]string{"3c311848183c9a212a26f27f8c6647e40375e466a0857cc39c4e47575d53f1f6", "fcb9abd19ccfbccef88c9c39bfb3dd7b1c12266c9808992e305bc3cff566e4e4"},
+		returnedBits:          "9c7b758b212cd0fcecd5daa489821712e3cdea4467b560ef5ddc24ab47749a1f1ffdbbb118f4e62fcfca3371b8fbfc5b0646b83e06bfbbab5fac30ea09ea2bc76f1ea568c9be0444b2cc90517b20ca825f2d0eccd88e7175538b85d90ab390183ca6395535d34473af6b5a5b88f5a59ee7561573337ea819da0dcc3573a22974",
+	},
+	{
+		entropyInput:          "5cacc68165a2e2ee20812f35ec73a79dbf30fd475476ac0c44fc6174cdac2b55",
+		nonce:                 "6f885496c1e63af620becd9e71ecb824",
+		personalizationString: "e72dd8590d4ed5295515c35ed6199e9d211b8f069b3058caa6670b96ef1208d0",
+		additionalInput:       [2]string{"", ""},
+		returnedBits:          "f1012cf543f94533df27fedfbf58e5b79a3dc517a9c402bdbfc9a0c0f721f9d53faf4aafdc4b8f7a1b580fcaa52338d4bd95f58966a243cdcd3f446ed4bc546d9f607b190dd69954450d16cd0e2d6437067d8b44d19a6af7a7cfa8794e5fbd728e8fb2f2e8db5dd4ff1aa275f35886098e80ff844886060da8b1e7137846b23b",
+	},
+	{
+		entropyInput:          "5d3286bc53a258a53ba781e2c4dcd79a790e43bbe0e89fb3eed39086be34174b",
+		nonce:                 "c5422294b7318952ace7055ab7570abf",
+		personalizationString: "2dba094d008e150d51c4135bb2f03dcde9cbf3468a12908a1b025c120c985b9d",
+		additionalInput:       [2]string{"793a7ef8f6f0482beac542bb785c10f8b7b406a4de92667ab168ecc2cf7573c6", "2238cdb4e23d629fe0c2a83dd8d5144ce1a6229ef41dabe2a99ff722e510b530"},
+		returnedBits:          "d04678198ae7e1aeb435b45291458ffde0891560748b43330eaf866b5a6385e74c6fa5a5a44bdb284d436e98d244018d6acedcdfa2e9f499d8089e4db86ae89a6ab2d19cb705e2f048f97fb597f04106a1fa6a1416ad3d859118e079a0c319eb95686f4cbcce3b5101c7a0b010ef029c4ef6d06cdfac97efb9773891688c37cf",
+	},
+	{
+		entropyInput:          "06032cd5eed33f39265f49ecb142c511da9aff2af71203bffaf34a9ca5bd9c0d",
+		nonce:                 "0e66f71edc43e42a45ad3c6fc6cdc4df",
+		personalizationString: "",
+		entropyInputReseed:    "01920a4e669ed3a85ae8a33b35a74ad7fb2a6bb4cf395ce00334a9c9a5a5d552",
+		additionalInputReseed: "",
+		additionalInput:       [2]string{"", ""},
+		returnedBits:          "76fc79fe9b50beccc991a11b5635783a83536add03c157fb30645e611c2898bb2b1bc215000209208cd506cb28da2a51bdb03826aaf2bd2335d576d519160842e7158ad0949d1a9ec3e66ea1b1a064b005de914eac2e9d4f2d72a8616a80225422918250ff66a41bd2f864a6a38cc5b6499dc43f7f2bd09e1e0f8f5885935124",
+	},
+	{
+		entropyInput:          "05ac9fc4c62a02e3f90840da5616218c6de5743d66b8e0fbf833759c5928b53d",
+		nonce:                 "2b89a17904922ed8f017a63044848545",
+		personalizationString: "",
+		entropyInputReseed:    "2791126b8b52ee1fd9392a0a13e0083bed4186dc649b739607ac70ec8dcecf9b",
+		additionalInputReseed: "43bac13bae715092cf7eb280a2e10a962faf7233c41412f69bc74a35a584e54c",
+		additionalInput:       [2]string{"3f2fed4b68d506ecefa21f3f5bb907beb0f17dbc30f6ffbba5e5861408c53a1e", "529030df50f410985fde068df82b935ec23d839cb4b269414c0ede6cffea5b68"},
+		returnedBits:          "02ddff5173da2fcffa10215b030d660d61179e61ecc22609b1151a75f1cbcbb4363c3a89299b4b63aca5e581e73c860491010aa35de3337cc6c09ebec8c91a6287586f3a74d9694b462d2720ea2e11bbd02af33adefb4a16e6b370fa0effd57d607547bdcfbb7831f54de7073ad2a7da987a0016a82fa958779a168674b56524",
+	},
+	{
+		entropyInput:          "fa0ee1fe39c7c390aa94159d0de97564342b591777f3e5f6a4ba2aea342ec840",
+		nonce:                 "dd0820655cb2ffdb0da9e9310a67c9e5",
+		personalizationString: "f2e58fe60a3afc59dad37595415ffd318ccf69d67780f6fa0797dc9aa43e144c",
+		entropyInputReseed:    "e0629b6d7975ddfa96a399648740e60f1f9557dc58b3d7415f9ba9d4dbb501f6",
+		additionalInputReseed: "",
+		additionalInput:       [2]string{"", ""},
+		returnedBits:          "f92d4cf99a535b20222a52a68db04c5af6f5ffc7b66a473a37a256bd8d298f9b4aa4af7e8d181e02367903f93bdb744c6c2f3f3472626b40ce9bd6a70e7b8f93992a16a76fab6b5f162568e08ee6c3e804aefd952ddd3acb791c50f2ad69e9a04028a06a9c01d3a62aca2aaf6efe69ed97a016213a2dd642b4886764072d9cbe",
+	},
+	{
+		entropyInput:          "cdb0d9117cc6dbc9ef9dcb06a97579841d72dc18b2d46a1cb61e314012bdf416",
+		nonce:                 "d0c0d01d156016d0eb6b7e9c7c3c8da8",
+		personalizationString: "6f0fb9eab3f9ea7ab0a719bfa879bf0aaed683307fda0c6d73ce018b6e34faaa",
+		entropyInputReseed:    "8ec6f7d5a8e2e88f43986f70b86e050d07c84b931bcf18e601c5a3eee3064c82",
+		additionalInputReseed: "1ab4ca9014fa98a55938316de8ba5a68c629b0741bdd058c4d70c91cda5099b3",
+		additionalInput:       [2]string{"16e2d0721b58d839a122852abd3bf2c942a31c84d82fca74211871880d7162ff", "53686f042a7b087d5d2eca0d2a96de131f275ed7151189f7ca52deaa78b79fb2"},
+		returnedBits:          "dda04a2ca7b8147af1548f5d086591ca4fd951a345ce52b3cd49d47e84aa31a183e31fbc42a1ff1d95afec7143c8008c97bc2a9c091df0a763848391f68cb4a366ad89857ac725a53b303ddea767be8dc5f605b1b95f6d24c9f06be65a973a089320b3cc42569dcfd4b92b62a993785b0301b3fc452445656fce22664827b88f",
+	},
+}
+
+// hmacDRBG512Vectors are taken from the sections "[SHA-512]" with
+// "[PredictionResistance = False]" of the CAVP files
+// no_reseed/HMAC_DRBG.rsp and pr_false/HMAC_DRBG.rsp.
+var hmacDRBG512Vectors = []drbgTestVector{
+	{
+		entropyInput:          "35049f389a33c0ecb1293238fd951f8ffd517dfde06041d32945b3e26914ba15",
+		nonce:                 "f7328760be6168e6aa9fb54784989a11",
+		personalizationString: "",
+		additionalInput:       [2]string{"", ""},
+		returnedBits:          "e76491b0260aacfded01ad39fbf1a66a88284caa5123368a2ad9330ee48335e3c9c9ba90e6cbc9429962d60c1a6661edcfaa31d972b8264b9d4562cf18494128a092c17a8da6f3113e8a7edfcd4427082bd390675e9662408144971717303d8dc352c9e8b95e7f35fa2ac9f549b292bc7c4bc7f01ee0a577859ef6e82d79ef23892d167c140d22aac32b64ccdfeee2730528a38763b24227f91ac3ffe47fb11538e435307e77481802b0f613f370ffb0dbeab774fe1efbb1a80d01154a9459e73ad361108bbc86b0914f095136cbe634555ce0bb263618dc5c367291ce0825518987154fe9ecb052b3f0a256fcc30cc14572531c9628973639beda456f2bddf6",
+	},
+	{
+		entropyInput:          "a3da06bc88e2f2ea5181292c194a10b3db38a11d02ac2f9c65951d0c71f63e36",
+		nonce:                 "c74e5e3d7ba0193bcd6839e9ae93d70d",
+		personalizationString: "",
+		additionalInput:       [2]string{"dbb7270760d8d262557807ce746ff314fd06598143611ab69bfc7e10ca5784b3", "8cdea882f894e5fdc5f0a0b16b7d9ac8cde35ed17bcaf2665564d4ee74059e29"},
+		returnedBits:          "cb706b90e88380e5c1864458454027821b571dfeba0da83f712efb107b8752099514ef87b4488fbfa3508a00954bb03090766d2bbd399e71c86c7967a4e8ded57095a29d4cfa01f8d28c97e81a4cd4fc5be7fb32a0d6c230cb8760e656b74fa7e18e2063ebee5787958b272fc5de93f0d6837e55f0c360dc593c88fff30a428cae37ded52f825646e04133a19790c304e4b1f040e10439c5edf454e6f71b23eeb43cdbe7b0634b8e283a97806073f7f28a43de2d0d969b3eda380c185b785b9101dc905025c9cdb499e594de0f0d3eb41922c20994fe2c403dd5bf01e4b2c3ee6654d6ab9cca7d4d5ae59525a796119547eae6a3cbf8ad0e9b1de3c4d5a804e4",
+	},
+	{
+		entropyInput:          "73529bba71a3d4b4fcf9a7edeed269dbdc3748b90df68c0d00e245de54698c77",
+		nonce:                 "22e2d6e24501212b6f058e7c54138007",
+		personalizationString: "e2cc19e31595d0e4de9e8bd3b236dec2d4b032c3dd5bf9891c284cd1bac67bdb",
+		additionalInput:       [2]string{"", ""},
+		returnedBits:          "1a73d58b7342c3c933e3ba15eedd8270988691c3794b45aa358570391571881c0d9c4289e5b198db5534c3cb8466ab48250fa67f24cb19b7038e46af56687bab7e5de3c82fa7312f54dc0f1dc93f5b03fcaa6003cae28d3d4707368c144a7aa46091822da292f97f32caf90ae3dd3e48e808ae12e633aa0410106e1ab56bc0a0d80f438e9b3492e4a3bc88d73a3904f7dd060c48ae8d7b12bf89a19551b53b3f55a511d2820e941640c845a8a0466432c5850c5b61bec5272602521125addf677e949b96782bc01a904491df08089bed004ad56e12f8ea1a200883ad72b3b9fae12b4eb65d5c2bacb3ce46c7c48464c9c29142fb35e7bc267ce852296ac042f9",
+	},
+	{
+		entropyInput:          "e97a4631d0a08d549cde8af9a1aae058e3e9585575a726c76a27bc62bed18a4b",
+		nonce:                 "227221d5fe5a5db9810f9afe56a3ee78",
+		personalizationString: "94084b11d55e0f9c2ef577741753af66ad7a25b28524b50ea970105c3545e97d",
+		additionalInput:       [2]string{"24c81d4773938371b906cf4801957ac22f87432b9c8a84bc5ac04ad5b1cc3f57", "c8c878451e2b76577c36393ca253888c1038885bbfdacd8539615a611e2ac00b"},
+		returnedBits:          "761422dea283262998c0ffffefc77de2d395c818b9cf1ac2bcd1153235e0d8b63199c51e195135a75f1f87b454484ecc560c532c7ba5923c9490a423c177453459d81efc38ce2939226043cb733062eae303a009b48ee0cf3c7e40abe2b57a70a6062c669a9fbff20b4c94b4ecbc5f744a80d7be8134359581d441da921737b1329470b214f3e679fb7ad48baf046bac59a36b5770806cdef28cc4a8fd0e049b924c3c9216e00ba63c2ff771d66b7520dd33a85382a84b622717e594e447c919926a5b2e94d490ee626da9df587fed674067917963fd51d383e55730c17a124555e2e46e1395c9920d07dae4d67ffee5c759b6a326eec6d7b3ba6dee012e4807",
+	},
+	{
+		entropyInput:          "48c121b18733af15c27e1dd9ba66a9a81a5579cdba0f5b657ec53c2b9e90bbf6",
+		nonce:                 "bbb7c777428068fad9970891f879b1af",
+		personalizationString: "",
+		entropyInputReseed:    "e0ffefdadb9ccf990504d568bdb4d862cbe17ccce6e22dfcab8b4804fd21421a",
+		additionalInputReseed: "",
+		additionalInput:       [2]string{"", ""},
+		returnedBits:          "05da6aac7d980da038f65f392841476d37fe70fbd3e369d1f80196e66e54b8fadb1d60e1a0f3d4dc173769d75fc3410549d7a843270a54a068b4fe767d7d9a59604510a875ad1e9731c8afd0fd50b825e2c50d062576175106a9981be37e02ec7c5cd0a69aa0ca65bddaee1b0de532e10cfa1f5bf6a026e47379736a099d6750ab121dbe3622b841baf8bdcbe875c85ba4b586b8b5b57b0fecbec08c12ff2a9453c47c6e32a52103d972c62ab9affb8e728a31fcefbbccc556c0f0a35f4b10ace2d96b906e36cbb72233201e536d3e13b045187b417d2449cad1edd192e061f12d22147b0a176ea8d9c4c35404395b6502ef333a813b6586037479e0fa3c6a23",
+	},
+	{
+		entropyInput:          "4686a959e17dfb96c294b09c0f7a60efb386416cfb4c8972bcc55e44a151607a",
+		nonce:                 "5226543b4c89321bbfb0f11f18ee3462",
+		personalizationString: "",
+		entropyInputReseed:    "5ef50daaf29929047870235c17762f5df5d9ab1af656e0e215fcc6fd9fc0d85d",
+		additionalInputReseed: "d2383c3e528492269e6c3b3aaa2b54fbf48731f5aa52150ce7fc644679a5e7c6",
+		additionalInput:       [2]string{"c841e7a2d9d13bdb8644cd7f5d91d241a369e12dc6c9c2be50d1ed29484bff98", "9054cf9216af66a788d3bf6757b8987e42d4e49b325e728dc645d5e107048245"},
+		returnedBits:          "b60d8803531b2b8583d17bdf3ac7c01f3c65cf9b069862b2d39b9024b34c172b712db0704acb078a1ab1aec0390dbaee2dec9be7b234e63da481fd469a92c77bc7bb2cfca586855520e0f9e9d47dcb9bdf2a2fdfa9f2b4342ef0ea582616b55477717cfd516d46d6383257743656f7cf8b38402ba795a8c9d35a4aa88bec623313dad6ead689d152b54074f183b2fee556f554db343626cea853718f18d386bc8bebb0c07b3c5e96ceb391ffceece88864dbd3be83a613562c5c417a24807d5f9332974f045e79a9ade36994af6cf9bbeeb71d0025fcb4ad50f121cbc2df7cd12ff5a50cddfd9a4bbc6d942d743c8b8fbebe00eeccea3d14e07ff8454fa715da",
+	},
+	{
+		entropyInput:          "97aef935ea33717e8e8644bb8c4789f375c48a945ded08771149e828a22dc866",
+		nonce:                 "82580f51070ba1e991d9803f51fd9a6f",
+		personalizationString: "212300f93899ff7cb144f20426028b976380a348253bcc3ff42b528cd1972549",
+		entropyInputReseed:    "63cd91c1ebb2caa15f2837df8f35cbb6fe96df2674a136990a5976cbbab63bc1",
+		additionalInputReseed: "",
+		additionalInput:       [2]string{"", ""},
+		returnedBits:          "0e8533f64b60c23a2655827037db218c2fe9ce430fa4ed6ed9be349c4bdc6f40018b42f486fa04288b3b0c62a12812e76e08c76062a510cc60841f165869efaceef90805bdde2fd66c36c38a2ac9c3cb86bfd30406569e0afd245102f2ea2d49e4ee5f69187227a3f0edfbc1259cb6564a2d4e829b3fc3b6996e37546f1d8a16fcd8201d1ad28661bbb0012daad55d5403e833d8a0068d216c879bcebc054df0c9cba14dad4863ee1f75b78bc488662cb0c91ca4fdfce7df5916b4e62580902c601be706dcc7903858e6b9920735bdaa635add5c06080d82265345b49037a32fcf0a7c9ea6069e3369f9b4aa45493efd7318da2ae9b4fc300498248afaad8d49",
+	},
+	{
+		entropyInput:          "da740cbc36057a8e282ae717fe7dfbb245e9e5d49908a0119c5dbcf0a1f2d5ab",
+		nonce:                 "46561ff612217ba3ff91baa06d4b5440",
+		personalizationString: "fc227293523ecb5b1e28c87863626627d958acc558a672b148ce19e2abd2dde4",
+		entropyInputReseed:    "1d61d4d8a41c3254b92104fd555adae0569d1835bb52657ec7fbba0fe03579c5",
+		additionalInputReseed: "b9ed8e35ad018a375b61189c8d365b00507cb1b4510d21cac212356b5bbaa8b2",
+		additionalInput:       [2]string{"b7998998eaf9e5d34e64ff7f03de765b31f407899d20535573e670c1b402c26a", "2089d49d63e0c4df58879d0cb1ba998e5b3d1a7786b785e7cf13ca5ea5e33cfd"},
+		returnedBits:          "5b70f3e4da95264233efbab155b828d4e231b67cc92757feca407cc9615a660871cb07ad1a2e9a99412feda8ee34dc9c57fa08d3f8225b30d29887d20907d12330fffd14d1697ba0756d37491b0a8814106e46c8677d49d9157109c402ad0c247a2f50cd5d99e538c850b906937a05dbb8888d984bc77f6ca00b0e3bc97b16d6d25814a54aa12143afddd8b2263690565d545f4137e593bb3ca88a37b0aadf79726b95c61906257e6dc47acd5b6b7e4b534243b13c16ad5a0a1163c0099fce43f428cd27c3e6463cf5e9a9621f4b3d0b3d4654316f4707675df39278d5783823049477dcce8c57fdbd576711c91301e9bd6bb0d3e72dc46d480ed8f61fd63811",
+	},
+}
+
+func TestHMACDRBGVectors(t *testing.T) {
+	testDRBGVectors(t, func() drbg { return NewHMACDRBG(sha256.New) },
+		hmacDRBG256Vectors)
+	testDRBGVectors(t, func() drbg { return NewHMACDRBG(sha512.New) },
+		hmacDRBG512Vectors)
+}
+
+func TestHMACDRBGErrors(t *testing.T) {
+	d := NewHMACDRBG(sha256.New)
+	buf := make([]byte, 16)
+	if err := d.Generate(buf, nil); err != ErrNotSeeded {
+		t.Errorf("uninstantiated DRBG not detected: %v", err)
+	}
+
+	entropy := make([]byte, 32)
+	nonce := make([]byte, 16)
+	if err := d.Instantiate(entropy[:31], nonce, nil); err != ErrShortInput {
+		t.Errorf("short entropy input not detected: %v", err)
+	}
+	if err := d.Instantiate(entropy, nonce[:15], nil); err != ErrShortInput {
+		t.Errorf("short nonce not detected: %v", err)
+	}
+	if err := d.Instantiate(entropy, nonce, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Reseed(entropy[:31], nil); err != ErrShortInput {
+		t.Errorf("short entropy input not detected: %v", err)
+	}
+	if err := d.Generate(make([]byte, drbgMaxRequest+1), nil); err != ErrRequestTooLarge {
+		t.Errorf("large request not detected: %v", err)
+	}
+
+	d.reseedCounter = drbgReseedInterval + 1
+	if err := d.Generate(buf, nil); err != ErrReseedRequired {
+		t.Errorf("exhausted reseed interval not detected: %v", err)
+	}
+	if err := d.Reseed(entropy, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Generate(buf, nil); err != nil {
+		t.Error(err)
+	}
+
+	d.Uninstantiate()
+	if !isZero(d.k) || !isZero(d.v) {
+		t.Error("state not wiped")
+	}
+	if err := d.Generate(buf, nil); err != ErrNotSeeded {
+		t.Errorf("uninstantiated DRBG not detected: %v", err)
+	}
+}
+
+func TestAccumulatorHMACDRBG(t *testing.T) {
+	acc, err := NewAccumulatorWithOptions(
+		WithHMACDRBG(sha512.New, []byte("test")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := acc.gen.(*HMACDRBG); !ok {
+		t.Fatal("wrong generator type")
+	}
+
+	for i := uint(0); i < 100; i++ {
+		acc.addRandomEvent(0, i, make([]byte, 32))
+	}
+	x := acc.RandomData(drbgMaxRequest + 100)
+	y := acc.RandomData(drbgMaxRequest + 100)
+	if bytes.Equal(x, y) {
+		t.Error("repeated output")
+	}
+
+	err = acc.Close()
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func BenchmarkHMACDRBG1k(b *testing.B) {
+	d, _ := newSeededHMACDRBG(sha256.New, nil)
+	buffer := make([]byte, 1024)
+
+	b.SetBytes(int64(len(buffer)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		d.Fill(buffer)
+	}
+}
+
+// compile-time test: HMACDRBG implements the RandomGenerator interface
+var _ RandomGenerator = &HMACDRBG{}
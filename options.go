@@ -0,0 +1,324 @@
+// options.go - configuration options for the Fortuna accumulator
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package fortuna
+
+import (
+	"crypto/aes"
+	"errors"
+	"fmt"
+	"hash"
+	"time"
+
+	"github.com/seehuhn/sha256d"
+)
+
+// minHashSize is the minimal output size, in bytes, of the hash
+// function used for the entropy pools.
+const minHashSize = 32
+
+// maxPools is the largest number of entropy pools an Accumulator can
+// use.  Pool i is used once every 2^i reseeds, so with a 64 bit reseed
+// counter additional pools would never be used.
+const maxPools = 64
+
+// ErrInvalidOption is returned by NewAccumulatorWithOptions() if the
+// given options are invalid or inconsistent.  The returned error
+// wraps ErrInvalidOption and describes the offending setting.
+var ErrInvalidOption = errors.New("invalid option")
+
+// config holds the settings used to construct an Accumulator.
+type config struct {
+	newCipher              NewCipher
+	newGenerator           func(*config) (RandomGenerator, error)
+	newHash                func() hash.Hash
+	generatorOptions       []GeneratorOption
+	seedFileName           string
+	numPools               int
+	minPoolSize            int
+	minReseedInterval      time.Duration
+	seedFileUpdateInterval time.Duration
+	maxSources             int
+	healthTest             bool
+	onHealthFailure        func(error)
+	secureMemory           bool
+}
+
+func defaultConfig() *config {
+	return &config{
+		newCipher:              aes.NewCipher,
+		newGenerator:           newFortunaGenerator,
+		newHash:                sha256d.New,
+		numPools:               numPools,
+		minPoolSize:            minPoolSize,
+		minReseedInterval:      minReseedInterval,
+		seedFileUpdateInterval: seedFileUpdateInterval,
+		maxSources:             maxSources,
+	}
+}
+
+func newFortunaGenerator(cfg *config) (RandomGenerator, error) {
+	return NewGeneratorE(cfg.newCipher, cfg.fortunaOptions()...)
+}
+
+// fortunaOptions returns the options for the Fortuna Generators used
+// by an Accumulator.
+func (cfg *config) fortunaOptions() []GeneratorOption {
+	opts := []GeneratorOption{ReseedHash(cfg.newHash)}
+	if cfg.secureMemory {
+		opts = append(opts, SecureMemory())
+	}
+	return append(opts, cfg.generatorOptions...)
+}
+
+// validate checks that the settings in cfg can be used together.
+func (cfg *config) validate() error {
+	if cfg.newCipher == nil {
+		return fmt.Errorf("%w: no block cipher given", ErrInvalidOption)
+	}
+	if cfg.newHash == nil {
+		return fmt.Errorf("%w: no hash function given", ErrInvalidOption)
+	}
+	if n := cfg.newHash().Size(); n < minHashSize {
+		return fmt.Errorf("%w: hash size %d is less than %d bytes",
+			ErrInvalidOption, n, minHashSize)
+	}
+	if cfg.numPools < 1 || cfg.numPools > maxPools {
+		return fmt.Errorf("%w: number of pools %d not in range 1, ..., %d",
+			ErrInvalidOption, cfg.numPools, maxPools)
+	}
+	if cfg.minPoolSize < 1 {
+		return fmt.Errorf("%w: minimal pool size %d is not positive",
+			ErrInvalidOption, cfg.minPoolSize)
+	}
+	if cfg.minReseedInterval <= 0 {
+		return fmt.Errorf("%w: minimal reseed interval %v is not positive",
+			ErrInvalidOption, cfg.minReseedInterval)
+	}
+	if cfg.seedFileUpdateInterval <= 0 {
+		return fmt.Errorf("%w: seed file update interval %v is not positive",
+			ErrInvalidOption, cfg.seedFileUpdateInterval)
+	}
+	if cfg.maxSources < 1 {
+		return fmt.Errorf("%w: maximal number of sources %d is not positive",
+			ErrInvalidOption, cfg.maxSources)
+	}
+	return nil
+}
+
+// An Option changes one setting of an Accumulator allocated by
+// NewAccumulatorWithOptions().  Settings which are not changed by any
+// option keep the default values described in the documentation of
+// the individual options.
+type Option func(*config)
+
+// WithCipher sets the block cipher used by the underlying generator.
+// The default is aes.NewCipher.
+func WithCipher(newCipher NewCipher) Option {
+	return func(cfg *config) {
+		cfg.newCipher = newCipher
+	}
+}
+
+// WithCTRDRBG selects the NIST SP 800-90A CTR_DRBG as the generator
+// of the Accumulator, instead of the Fortuna Generator.  The block
+// cipher chosen using WithCipher() must accept 256 bit keys.  The
+// optional personalization string is used when the DRBG is
+// instantiated.
+func WithCTRDRBG(personalization []byte) Option {
+	personalization = append([]byte{}, personalization...)
+	return func(cfg *config) {
+		cfg.newGenerator = func(cfg *config) (RandomGenerator, error) {
+			return newSeededCTRDRBG(cfg.newCipher, personalization)
+		}
+	}
+}
+
+// WithChaCha selects the ChaCha20-based ChaChaGenerator as the
+// generator of the Accumulator, instead of the block cipher based
+// Fortuna Generator.  Any block cipher set using WithCipher() and any
+// options set using WithGeneratorOptions() are ignored.  The hash
+// function set using WithHash() is used for reseeding.
+func WithChaCha() Option {
+	return func(cfg *config) {
+		cfg.newGenerator = func(cfg *config) (RandomGenerator, error) {
+			return newChaChaGenerator(cfg.newHash)
+		}
+	}
+}
+
+// WithCombinedCipher selects a CombinedGenerator as the generator of
+// the Accumulator.  The output of a Fortuna Generator using the block
+// cipher set by WithCipher() is XORed with the output of a second
+// Fortuna Generator using newCipher, so that the Accumulator remains
+// secure if one of the two ciphers is broken.  The two ciphers must be
+// different, otherwise NewAccumulatorWithOptions() returns an error
+// wrapping ErrCipherInit.  The options set using
+// WithGeneratorOptions() and the hash set using WithHash() apply to
+// both generators.  Reseeding and the seed file work as for the
+// default generator.
+func WithCombinedCipher(newCipher NewCipher) Option {
+	return func(cfg *config) {
+		cfg.newGenerator = func(cfg *config) (RandomGenerator, error) {
+			return NewCombinedGenerator(cfg.newCipher, newCipher,
+				cfg.fortunaOptions()...)
+		}
+	}
+}
+
+// WithHMACDRBG selects the NIST SP 800-90A HMAC_DRBG as the generator
+// of the Accumulator, instead of the Fortuna Generator.  The argument
+// newHash chooses the hash function, normally sha256.New or
+// sha512.New.  The optional personalization string is used when the
+// DRBG is instantiated.  Any block cipher set using WithCipher() is
+// ignored.
+func WithHMACDRBG(newHash func() hash.Hash, personalization []byte) Option {
+	personalization = append([]byte{}, personalization...)
+	return func(cfg *config) {
+		cfg.newGenerator = func(*config) (RandomGenerator, error) {
+			return newSeededHMACDRBG(newHash, personalization)
+		}
+	}
+}
+
+// WithHashDRBG selects the NIST SP 800-90A Hash_DRBG as the generator
+// of the Accumulator, instead of the Fortuna Generator.  The argument
+// newHash chooses the hash function, normally sha256.New or
+// sha512.New.  The optional personalization string is used when the
+// DRBG is instantiated.  Any block cipher set using WithCipher() is
+// ignored.
+func WithHashDRBG(newHash func() hash.Hash, personalization []byte) Option {
+	personalization = append([]byte{}, personalization...)
+	return func(cfg *config) {
+		cfg.newGenerator = func(*config) (RandomGenerator, error) {
+			return newSeededHashDRBG(newHash, personalization)
+		}
+	}
+}
+
+// WithHash sets the hash function used for the entropy pools, for
+// shortening long entropy events submitted via Source.Add(), and for
+// reseeding the generator, for example sha512.New512_256 or
+// sha3.New256.  The hash must produce at least 32 bytes of output.
+// For the Fortuna Generator, this is the same as passing ReseedHash()
+// to WithGeneratorOptions(); the NIST SP 800-90A generators only use
+// the hash for the entropy pools.  The default is SHA-256d.
+func WithHash(newHash func() hash.Hash) Option {
+	return func(cfg *config) {
+		cfg.newHash = newHash
+	}
+}
+
+// WithGeneratorOptions sets options for the Fortuna Generator used by
+// the Accumulator, see NewGenerator().  The options are ignored if one
+// of the NIST SP 800-90A generators is selected.  By default, no
+// options are used.
+func WithGeneratorOptions(opts ...GeneratorOption) Option {
+	opts = append([]GeneratorOption{}, opts...)
+	return func(cfg *config) {
+		cfg.generatorOptions = append(cfg.generatorOptions, opts...)
+	}
+}
+
+// WithSeedFile sets the name of the seed file.  See the documentation
+// of NewRNG() for details about seed files.  By default, no seed file
+// is used.
+func WithSeedFile(seedFileName string) Option {
+	return func(cfg *config) {
+		cfg.seedFileName = seedFileName
+	}
+}
+
+// WithNumPools sets the number of entropy pools.  The number must be
+// in the range 1, ..., 64.  The default is 32 pools.
+func WithNumPools(n int) Option {
+	return func(cfg *config) {
+		cfg.numPools = n
+	}
+}
+
+// WithMinPoolSize sets the number of bytes which must have been
+// submitted to the first entropy pool before the generator is
+// reseeded.  The default is 32 bytes.
+func WithMinPoolSize(n int) Option {
+	return func(cfg *config) {
+		cfg.minPoolSize = n
+	}
+}
+
+// WithMinReseedInterval sets the minimal time between two reseeds of
+// the generator.  The default is 100ms.
+func WithMinReseedInterval(d time.Duration) Option {
+	return func(cfg *config) {
+		cfg.minReseedInterval = d
+	}
+}
+
+// WithSeedFileUpdateInterval sets the time between two automatic
+// updates of the seed file.  The setting is ignored if no seed file is
+// used.  The default is 10 minutes.
+func WithSeedFileUpdateInterval(d time.Duration) Option {
+	return func(cfg *config) {
+		cfg.seedFileUpdateInterval = d
+	}
+}
+
+// WithMaxSources sets the maximal number of entropy sources, allocated
+// by NewEntropyDataSinkE() and the related methods, which can be active
+// at the same time.  Once the limit is reached, allocating a new source
+// fails with ErrTooManySources until one of the existing sources is
+// closed.  The default is 256 sources.
+func WithMaxSources(n int) Option {
+	return func(cfg *config) {
+		cfg.maxSources = n
+	}
+}
+
+// WithSecureMemory keeps the seeds used to reseed the generator, and
+// the data read from and written to the seed file, in memory which is
+// locked into RAM and excluded from core dumps.  For the Fortuna
+// Generator and the CombinedGenerator, the generator state is kept in
+// locked memory as well, see SecureMemory(); the other generators keep
+// their state in ordinary memory.  The locked memory is wiped and
+// released by Close().  If locked memory is not available, ordinary
+// memory is used and the fallback is recorded in the statistics
+// returned by SecureMemoryStats().  By default, ordinary memory is
+// used.
+func WithSecureMemory() Option {
+	return func(cfg *config) {
+		cfg.secureMemory = true
+	}
+}
+
+// WithHealthTest enables the continuous health test of the generator,
+// see Generator.EnableHealthTest().  Once the test has failed, Read()
+// returns an error wrapping ErrHealthTest, while RandomData(),
+// FillBytes() and the methods of the rand.Source interface panic.  If
+// onFailure is not nil, it is called once, with the error as its
+// argument, when the failure is first detected.  The function is
+// called while internal locks are held and must not call methods of
+// the Accumulator.  The health test is only supported by the default
+// Fortuna Generator and by the CombinedGenerator selected using
+// WithCombinedCipher().  In combination with WithChaCha(),
+// WithCTRDRBG(), WithHMACDRBG() or WithHashDRBG(),
+// NewAccumulatorWithOptions() returns an error wrapping
+// ErrInvalidOption.  By default, the health test is disabled.
+func WithHealthTest(onFailure func(error)) Option {
+	return func(cfg *config) {
+		cfg.healthTest = true
+		cfg.onHealthFailure = onFailure
+	}
+}
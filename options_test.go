@@ -0,0 +1,137 @@
+// options_test.go - unit tests for options.go
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package fortuna
+
+import (
+	"crypto/sha1"
+	"crypto/sha512"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/seehuhn/sha256d"
+)
+
+func TestInvalidOptions(t *testing.T) {
+	invalid := [][]Option{
+		{WithCipher(nil)},
+		{WithNumPools(0)},
+		{WithNumPools(maxPools + 1)},
+		{WithMinPoolSize(0)},
+		{WithMinReseedInterval(0)},
+		{WithMinReseedInterval(-time.Second)},
+		{WithSeedFileUpdateInterval(0)},
+		{WithNumPools(8), WithNumPools(65)},
+		{WithHash(nil)},
+		{WithMaxSources(0)},
+		{WithHash(sha1.New)},
+	}
+	for i, opts := range invalid {
+		acc, err := NewAccumulatorWithOptions(opts...)
+		if !errors.Is(err, ErrInvalidOption) {
+			t.Errorf("%d: invalid options not detected: %v", i, err)
+		}
+		if acc != nil {
+			t.Errorf("%d: Accumulator returned together with error", i)
+		}
+	}
+}
+
+func TestOptions(t *testing.T) {
+	acc, err := NewAccumulatorWithOptions(
+		WithNumPools(maxPools),
+		WithMinPoolSize(64),
+		WithMinReseedInterval(time.Second),
+		WithSeedFileUpdateInterval(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer acc.Close()
+
+	if len(acc.pool) != maxPools {
+		t.Errorf("wrong number of pools: %d", len(acc.pool))
+	}
+	if acc.minPoolSize != 64 {
+		t.Errorf("wrong minimal pool size: %d", acc.minPoolSize)
+	}
+	if acc.minReseedInterval != time.Second {
+		t.Errorf("wrong reseed interval: %v", acc.minReseedInterval)
+	}
+
+	// The first pool alone must not trigger a reseed before 64 bytes
+	// have been submitted.
+	acc.addRandomEvent(0, 0, make([]byte, 32))
+	if seed := acc.tryReseeding(); seed != nil {
+		t.Error("reseeding with too little entropy")
+	}
+	acc.addRandomEvent(0, 0, make([]byte, 32))
+	if seed := acc.tryReseeding(); len(seed) != sha256d.Size {
+		t.Errorf("wrong seed length %d", len(seed))
+	}
+
+	// With 64 pools, all pools are used once the reseed count is a
+	// multiple of 2^63.
+	acc.reseedCount = 1<<63 - 1
+	acc.nextReseed = time.Time{}
+	acc.addRandomEvent(0, 0, make([]byte, 64))
+	if seed := acc.tryReseeding(); len(seed) != maxPools*sha256d.Size {
+		t.Errorf("wrong seed length %d", len(seed))
+	}
+}
+
+func TestFewPools(t *testing.T) {
+	acc, err := NewAccumulatorWithOptions(WithNumPools(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer acc.Close()
+
+	// Once the reseed count is divisible by 4, the pool count limits
+	// the number of pools used.
+	acc.reseedCount = 3
+	acc.addRandomEvent(0, 0, make([]byte, 32))
+	if seed := acc.tryReseeding(); len(seed) != 2*sha256d.Size {
+		t.Errorf("wrong seed length %d", len(seed))
+	}
+}
+
+func TestWithHash(t *testing.T) {
+	acc, err := NewAccumulatorWithOptions(WithHash(sha512.New))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer acc.Close()
+
+	gen := acc.gen.(*Generator)
+	if gen.newHash().Size() != sha512.Size {
+		t.Error("hash function not used for reseeding")
+	}
+
+	acc.addRandomEvent(0, 0, make([]byte, 32))
+	if seed := acc.tryReseeding(); len(seed) != sha512.Size {
+		t.Errorf("wrong seed length %d", len(seed))
+	}
+
+	for _, opt := range []Option{WithChaCha(), WithHMACDRBG(sha512.New, nil)} {
+		acc, err := NewAccumulatorWithOptions(WithHash(sha512.New512_256), opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		acc.RandomData(100)
+		acc.Close()
+	}
+}
@@ -0,0 +1,126 @@
+// parallel.go - multi-core generation of large requests
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package fortuna
+
+import (
+	"crypto/cipher"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// parallelBatch is the maximal number of chunks for which keys are
+// derived in advance.  This limits the memory used for key schedules
+// during very large requests.
+const parallelBatch = 64
+
+// Parallel allows the generator to use n goroutines for large
+// requests.  If n is less than 1, runtime.GOMAXPROCS(0) goroutines
+// are used.  The output of the generator is unchanged: the generator
+// still produces its output in chunks of 2^16 blocks and rekeys after
+// every chunk, but the keys for the chunks are derived in advance so
+// that the chunks can be encrypted concurrently.  Only requests of at
+// least two chunks (2 MiB for AES) are split between goroutines.
+//
+// Parallel generation is not used while the continuous health test
+// (see EnableHealthTest()) is enabled, since the test needs to compare
+// consecutive blocks.
+func Parallel(n int) GeneratorOption {
+	if n < 1 {
+		n = runtime.GOMAXPROCS(0)
+	}
+	return func(gen *Generator) {
+		gen.workers = n
+	}
+}
+
+// useParallel reports whether a request of n bytes should be generated
+// using fillParallel().
+func (gen *Generator) useParallel(n int) bool {
+	chunkSize := gen.rekeyBlocks * len(gen.counter)
+	return gen.workers > 1 && !gen.healthTest && n >= 2*chunkSize
+}
+
+// fillParallel fills dst, whose length must be a multiple of the chunk
+// size, with the same output as the sequential code in fill(): every
+// chunk is generated using the current key and counter, and the key
+// is replaced after every chunk.  Since the key for chunk j+1 only
+// depends on the key for chunk j and on the counter, the keys can be
+// derived before the chunks themselves are encrypted.
+func (gen *Generator) fillParallel(dst []byte) error {
+	bs := len(gen.counter)
+	chunkSize := gen.rekeyBlocks * bs
+
+	ciphers := make([]cipher.Block, parallelBatch)
+	counters := make([]byte, parallelBatch*bs)
+	defer wipe(counters)
+	for len(dst) > 0 {
+		n := len(dst) / chunkSize
+		if n > parallelBatch {
+			n = parallelBatch
+		}
+
+		for j := 0; j < n; j++ {
+			ciphers[j] = gen.cipher
+			copy(counters[j*bs:], gen.counter)
+			addCounter(gen.counter, uint64(gen.rekeyBlocks))
+			err := gen.rekey()
+			if err != nil {
+				wipe(dst)
+				return err
+			}
+		}
+		gen.encryptChunks(dst[:n*chunkSize], ciphers[:n], counters)
+		for j := range ciphers {
+			ciphers[j] = nil
+		}
+
+		dst = dst[n*chunkSize:]
+	}
+	return nil
+}
+
+// encryptChunks generates chunk j of dst using ciphers[j] and the j-th
+// counter block in counters.  The work is distributed over up to
+// gen.workers goroutines.
+func (gen *Generator) encryptChunks(dst []byte, ciphers []cipher.Block, counters []byte) {
+	bs := len(gen.counter)
+	chunkSize := gen.rekeyBlocks * bs
+	n := len(ciphers)
+
+	workers := gen.workers
+	if workers > n {
+		workers = n
+	}
+	next := int32(-1)
+	wg := &sync.WaitGroup{}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				j := int(atomic.AddInt32(&next, 1))
+				if j >= n {
+					return
+				}
+				encryptCounters(ciphers[j], counters[j*bs:(j+1)*bs],
+					dst[j*chunkSize:(j+1)*chunkSize])
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,98 @@
+// parallel_test.go - unit tests for parallel.go
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package fortuna
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/des"
+	"testing"
+)
+
+func TestAddCounter(t *testing.T) {
+	a := []byte{0xff, 0xff, 0x01, 0x00}
+	b := append([]byte{}, a...)
+	for i := 0; i < 1000; i++ {
+		incCounter(a)
+	}
+	addCounter(b, 1000)
+	if !bytes.Equal(a, b) {
+		t.Errorf("wrong counter %v, expected %v", b, a)
+	}
+}
+
+func TestParallel(t *testing.T) {
+	type testCase struct {
+		newCipher NewCipher
+		opts      []GeneratorOption
+		n         uint
+	}
+	aesChunk := uint(maxBlocks * aes.BlockSize)
+	desChunk := uint(256 * des.BlockSize)
+	cases := []testCase{
+		{aes.NewCipher, nil, 2 * aesChunk},
+		{aes.NewCipher, nil, 3*aesChunk + 123},
+		{aes.NewCipher, []GeneratorOption{FastKeyErasure()}, 3*aesChunk + 123},
+		{des.NewTripleDESCipher, []GeneratorOption{AllowSmallBlocks()},
+			(parallelBatch+7)*desChunk + 5},
+	}
+	for i, c := range cases {
+		seq := NewGenerator(c.newCipher, c.opts...)
+		par := NewGenerator(c.newCipher, append(c.opts, Parallel(4))...)
+		seq.Seed(int64(i))
+		par.Seed(int64(i))
+
+		seq.PseudoRandomData(10)
+		par.PseudoRandomData(10)
+		x := seq.PseudoRandomData(c.n)
+		y := par.PseudoRandomData(c.n)
+		if !bytes.Equal(x, y) {
+			t.Errorf("%d: parallel output differs", i)
+		}
+
+		// the generators must end up in the same state
+		if !bytes.Equal(seq.PseudoRandomData(100), par.PseudoRandomData(100)) {
+			t.Errorf("%d: generator states differ", i)
+		}
+	}
+}
+
+func TestParallelHealthTest(t *testing.T) {
+	gen := NewGenerator(aes.NewCipher, Parallel(4))
+	if !gen.useParallel(1 << 30) {
+		t.Error("parallel generation not used")
+	}
+	gen.EnableHealthTest()
+	if gen.useParallel(1 << 30) {
+		t.Error("parallel generation used together with the health test")
+	}
+}
+
+func generatorParallel(b *testing.B, opts ...GeneratorOption) {
+	rng := NewGenerator(aes.NewCipher, opts...)
+	rng.Seed(0)
+	buffer := make([]byte, 64<<20)
+
+	b.SetBytes(int64(len(buffer)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rng.FillBytes(buffer)
+	}
+}
+
+func BenchmarkGeneratorSequential64M(b *testing.B) { generatorParallel(b) }
+func BenchmarkGeneratorParallel64M(b *testing.B)   { generatorParallel(b, Parallel(0)) }
@@ -0,0 +1,154 @@
+// securemem.go - locked memory for secret generator state
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package fortuna
+
+import (
+	"os"
+	"runtime"
+	"sync"
+)
+
+// secureRegionSize is the size of the locked memory region used by a
+// Generator.  This is enough for the key, the counter and all buffers
+// of a Generator in fast-key-erasure mode with the health test
+// enabled.
+const secureRegionSize = 4096
+
+// SecureMemory makes the generator keep its key, its counter and all
+// buffers holding output in memory which is locked into RAM, so that
+// it is never written to swap, and which is excluded from core dumps.
+// The memory is wiped and released by Destroy(), or when the generator
+// is garbage collected.
+//
+// Locked memory is currently only supported on Linux.  If the memory
+// cannot be allocated, for example because the RLIMIT_MEMLOCK limit
+// has been reached, the generator silently falls back to ordinary
+// memory.  Such fallbacks are counted in the statistics returned by
+// SecureMemoryStats().  The state of the hash function used for
+// reseeding and the expanded key inside the cipher.Block are always
+// kept in ordinary memory.
+func SecureMemory() GeneratorOption {
+	return func(gen *Generator) {
+		gen.secureMem = true
+	}
+}
+
+// MemoryStats describes the use of locked memory by the generators and
+// Accumulators of this package, see SecureMemory() and
+// WithSecureMemory().
+type MemoryStats struct {
+	// Regions is the number of locked memory regions currently in use.
+	Regions int
+
+	// Bytes is the total size of these regions.
+	Bytes int
+
+	// Fallbacks counts how often ordinary memory had to be used
+	// because locked memory could not be allocated.
+	Fallbacks int
+
+	// LastError is the reason for the most recent fallback.
+	LastError error
+}
+
+var (
+	memStatsMutex sync.Mutex
+	memStats      MemoryStats
+)
+
+// SecureMemoryStats returns statistics about the use of locked memory.
+// If Fallbacks is non-zero, some secrets are kept in ordinary memory.
+func SecureMemoryStats() MemoryStats {
+	memStatsMutex.Lock()
+	defer memStatsMutex.Unlock()
+	return memStats
+}
+
+// secureRegion is a region of locked memory, from which buffers for
+// secret data are handed out.
+type secureRegion struct {
+	buf  []byte
+	used int
+}
+
+// newSecureRegion allocates a locked memory region of at least n
+// bytes.  If this fails, the failure is recorded in the statistics and
+// nil is returned.
+func newSecureRegion(n int) *secureRegion {
+	pageSize := os.Getpagesize()
+	n = (n + pageSize - 1) / pageSize * pageSize
+	buf, err := lockedAlloc(n)
+
+	memStatsMutex.Lock()
+	defer memStatsMutex.Unlock()
+	if err != nil {
+		memStats.Fallbacks++
+		memStats.LastError = err
+		return nil
+	}
+	memStats.Regions++
+	memStats.Bytes += len(buf)
+
+	r := &secureRegion{buf: buf}
+	runtime.SetFinalizer(r, (*secureRegion).free)
+	return r
+}
+
+// alloc returns a zeroed buffer of n bytes from the region, or nil if
+// not enough space is left.
+func (r *secureRegion) alloc(n int) []byte {
+	if r == nil || r.used+n > len(r.buf) {
+		return nil
+	}
+	buf := r.buf[r.used : r.used+n : r.used+n]
+	r.used += n
+	return buf
+}
+
+// reset wipes the region and makes all of it available again.  All
+// buffers previously obtained from alloc() must no longer be used.
+func (r *secureRegion) reset() {
+	wipe(r.buf[:r.used])
+	r.used = 0
+}
+
+// free wipes and releases the region.  Calling free more than once, or
+// on a nil region, is harmless.
+func (r *secureRegion) free() {
+	if r == nil || r.buf == nil {
+		return
+	}
+	n := len(r.buf)
+	lockedFree(r.buf)
+	r.buf = nil
+	r.used = 0
+	runtime.SetFinalizer(r, nil)
+
+	memStatsMutex.Lock()
+	memStats.Regions--
+	memStats.Bytes -= n
+	memStatsMutex.Unlock()
+}
+
+// newSecret allocates a buffer for n bytes of secret data, using the
+// locked memory region of the generator if possible.
+func (gen *Generator) newSecret(n int) []byte {
+	if buf := gen.mem.alloc(n); buf != nil {
+		return buf
+	}
+	return make([]byte, n)
+}
@@ -0,0 +1,39 @@
+// +build linux
+
+package fortuna
+
+import "syscall"
+
+// madvDontDump is MADV_DONTDUMP from <sys/mman.h>, which is not
+// defined in the syscall package.
+const madvDontDump = 0x10
+
+// lockedAlloc allocates n bytes of memory, which is locked into RAM
+// and excluded from core dumps.  The length n must be a multiple of
+// the page size.
+func lockedAlloc(n int) ([]byte, error) {
+	buf, err := syscall.Mmap(-1, 0, n, syscall.PROT_READ|syscall.PROT_WRITE,
+		syscall.MAP_PRIVATE|syscall.MAP_ANON)
+	if err != nil {
+		return nil, err
+	}
+	err = syscall.Mlock(buf)
+	if err == nil {
+		err = syscall.Madvise(buf, madvDontDump)
+		if err != nil {
+			syscall.Munlock(buf)
+		}
+	}
+	if err != nil {
+		syscall.Munmap(buf)
+		return nil, err
+	}
+	return buf, nil
+}
+
+// lockedFree wipes and releases memory allocated by lockedAlloc().
+func lockedFree(buf []byte) {
+	wipe(buf)
+	syscall.Munlock(buf)
+	syscall.Munmap(buf)
+}
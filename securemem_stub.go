@@ -0,0 +1,14 @@
+// +build !linux
+
+package fortuna
+
+import "errors"
+
+// lockedAlloc is not supported on this operating system.  The stub
+// always returns an error, so that ordinary memory is used instead.
+func lockedAlloc(n int) ([]byte, error) {
+	return nil, errors.New("locked memory not supported")
+}
+
+// lockedFree is never called, since lockedAlloc() always fails.
+func lockedFree(buf []byte) {}
@@ -0,0 +1,204 @@
+// securemem_test.go - unit tests for securemem.go
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package fortuna
+
+import (
+	"bytes"
+	"crypto/aes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// inRegion reports whether buf is located inside the memory region r.
+func inRegion(r *secureRegion, buf []byte) bool {
+	if r == nil || len(buf) == 0 {
+		return false
+	}
+	for i := range r.buf {
+		if &r.buf[i] == &buf[0] {
+			return i+len(buf) <= len(r.buf)
+		}
+	}
+	return false
+}
+
+func TestSecureMemory(t *testing.T) {
+	before := SecureMemoryStats()
+	gen := NewGenerator(aes.NewCipher, SecureMemory(), FastKeyErasure())
+	if gen.mem == nil {
+		stats := SecureMemoryStats()
+		if stats.Fallbacks <= before.Fallbacks || stats.LastError == nil {
+			t.Error("fallback not recorded")
+		}
+		t.Skipf("locked memory not available: %v", stats.LastError)
+	}
+	if stats := SecureMemoryStats(); stats.Regions != before.Regions+1 {
+		t.Errorf("wrong number of regions: %d", stats.Regions)
+	}
+
+	gen.EnableHealthTest()
+	gen.Seed(1)
+	for _, buf := range [][]byte{gen.key, gen.counter, gen.block,
+		gen.keyBuf, gen.fkeBuf, gen.prevBlock} {
+		if !inRegion(gen.mem, buf) {
+			t.Error("secret buffer outside locked memory")
+		}
+	}
+
+	// the output must not depend on the location of the buffers
+	ref := NewGenerator(aes.NewCipher, FastKeyErasure())
+	ref.EnableHealthTest()
+	ref.Seed(1)
+	if !bytes.Equal(gen.PseudoRandomData(1000), ref.PseudoRandomData(1000)) {
+		t.Error("output differs from generator in ordinary memory")
+	}
+
+	child := gen.Split(nil)
+	if child.mem == nil || child.mem == gen.mem {
+		t.Error("child does not use its own locked memory")
+	}
+	child.Destroy()
+
+	gen.Destroy()
+	if stats := SecureMemoryStats(); stats.Regions != before.Regions {
+		t.Errorf("locked memory not released: %d regions", stats.Regions)
+	}
+}
+
+func TestSecureMemoryUnmarshal(t *testing.T) {
+	before := SecureMemoryStats()
+	gen := NewGenerator(aes.NewCipher, SecureMemory())
+	if gen.mem == nil {
+		t.Skip("locked memory not available")
+	}
+	gen.Seed(1)
+	state, err := gen.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := gen.PseudoRandomData(100)
+
+	// the old region must be released when the state is replaced
+	for i := 0; i < 3; i++ {
+		err = gen.UnmarshalBinary(state)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stats := SecureMemoryStats(); stats.Regions != before.Regions+1 {
+			t.Errorf("%d: wrong number of regions: %d", i, stats.Regions)
+		}
+	}
+	if !inRegion(gen.mem, gen.key) {
+		t.Error("restored key outside locked memory")
+	}
+	if !bytes.Equal(gen.PseudoRandomData(100), expected) {
+		t.Error("wrong output after UnmarshalBinary")
+	}
+
+	gen.Destroy()
+	if stats := SecureMemoryStats(); stats.Regions != before.Regions {
+		t.Errorf("locked memory not released: %d regions", stats.Regions)
+	}
+}
+
+func TestSecureRegion(t *testing.T) {
+	r := &secureRegion{buf: make([]byte, 10)}
+	a := r.alloc(6)
+	if len(a) != 6 || cap(a) != 6 {
+		t.Errorf("wrong buffer size %d/%d", len(a), cap(a))
+	}
+	if r.alloc(5) != nil {
+		t.Error("allocation beyond the end of the region")
+	}
+	a[0] = 1
+	r.reset()
+	if !isZero(r.buf) || r.alloc(10) == nil {
+		t.Error("region not reset")
+	}
+
+	var missing *secureRegion
+	if missing.alloc(1) != nil {
+		t.Error("allocation from nil region")
+	}
+}
+
+func TestAccumulatorSecureMemory(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+	seedFileName := filepath.Join(tempDir, "seed")
+
+	before := SecureMemoryStats()
+	for i := 0; i < 2; i++ {
+		acc, err := NewAccumulatorWithOptions(WithSeedFile(seedFileName),
+			WithSecureMemory())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if acc.mem == nil {
+			t.Skip("locked memory not available")
+		}
+		if !inRegion(acc.mem, acc.poolSeed) || !inRegion(acc.mem, acc.fileSeed) {
+			t.Error("seed buffers outside locked memory")
+		}
+		if acc.gen.(*Generator).mem == nil {
+			t.Error("generator does not use locked memory")
+		}
+
+		acc.addRandomEvent(0, 0, make([]byte, 32))
+		acc.RandomData(100)
+		err = acc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if stats := SecureMemoryStats(); stats.Regions != before.Regions {
+		t.Errorf("locked memory not released: %d regions", stats.Regions)
+	}
+}
+
+func TestAccumulatorSecureMemoryFailure(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+	insecureName := filepath.Join(tempDir, "insecure")
+	err = ioutil.WriteFile(insecureName, nil, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	missingName := filepath.Join(tempDir, "missing", "seed")
+
+	// Locked memory must be released when the constructor fails.
+	before := SecureMemoryStats()
+	for _, seedFileName := range []string{insecureName, missingName} {
+		_, err := NewAccumulatorWithOptions(WithSeedFile(seedFileName),
+			WithSecureMemory())
+		if err == nil {
+			t.Fatalf("%s: no error", seedFileName)
+		}
+		if stats := SecureMemoryStats(); stats.Regions != before.Regions {
+			t.Errorf("%s: locked memory not released: %d regions",
+				seedFileName, stats.Regions)
+		}
+	}
+}
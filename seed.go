@@ -80,17 +80,27 @@ func (acc *Accumulator) updateSeedFile() error {
 
 	n := fi.Size()
 	if n == seedFileSize {
-		seed := make([]byte, seedFileSize)
+		seed := acc.seedBuffer(acc.fileSeed, seedFileSize)[:seedFileSize]
 		_, err := io.ReadFull(acc.seedFile, seed)
 		if err != nil || isZero(seed) {
+			wipe(seed)
 			return ErrCorruptedSeed
 		}
-		acc.gen.Reseed(seed)
+		err = acc.gen.ReseedE(seed)
+		wipe(seed)
+		if err != nil {
+			return err
+		}
 	} else if n != 0 {
 		return ErrCorruptedSeed
 	}
 
-	seed := acc.randomDataUnlocked(seedFileSize)
+	seed := acc.seedBuffer(acc.fileSeed, seedFileSize)[:seedFileSize]
+	defer wipe(seed)
+	err = acc.fillUnlocked(seed)
+	if err != nil {
+		return err
+	}
 	return doWriteSeed(acc.seedFile, seed)
 }
 
@@ -98,7 +108,19 @@ func (acc *Accumulator) updateSeedFile() error {
 // file.  If the seed file cannot be written, a non-nil error is
 // returned.  In this case, the random number generator should not be
 // used until the problem is resolved.
+//
+// The generator is only locked while the seed is generated, so that
+// other users of the Accumulator do not wait for the disk.  The seed
+// buffer is only used by the auto-save goroutine and by Close(), which
+// never run at the same time.
 func (acc *Accumulator) writeSeedFile() error {
-	seed := acc.RandomData(seedFileSize)
+	acc.genMutex.Lock()
+	seed := acc.seedBuffer(acc.fileSeed, seedFileSize)[:seedFileSize]
+	err := acc.fillUnlocked(seed)
+	acc.genMutex.Unlock()
+	defer wipe(seed)
+	if err != nil {
+		return err
+	}
 	return doWriteSeed(acc.seedFile, seed)
 }
@@ -50,7 +50,7 @@ func TestSeedfile(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	rng.gen.reset()
+	rng.gen.(*Generator).reset()
 	before, err := ioutil.ReadFile(seedFileName)
 	if err != nil {
 		t.Error(err)
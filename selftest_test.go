@@ -0,0 +1,81 @@
+// selftest_test.go - unit tests for selftest.go
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package fortuna
+
+import (
+	"crypto/aes"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestSelfTest(t *testing.T) {
+	err := SelfTest()
+	if err != nil {
+		t.Error(err)
+	}
+	err = powerOnSelfTest()
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSelfTestFailure(t *testing.T) {
+	// make sure the power-on self test has run, then fake a failure
+	powerOnSelfTest()
+	saved := selfTestErr
+	defer func() { selfTestErr = saved }()
+	selfTestErr = fmt.Errorf("%w: simulated failure", ErrSelfTest)
+
+	gen, err := NewGeneratorE(aes.NewCipher)
+	if !errors.Is(err, ErrSelfTest) || gen != nil {
+		t.Errorf("NewGeneratorE: wrong result %v, %v", gen, err)
+	}
+
+	acc, err := NewAccumulatorWithOptions()
+	if !errors.Is(err, ErrSelfTest) || acc != nil {
+		t.Errorf("NewAccumulatorWithOptions: wrong result %v, %v", acc, err)
+	}
+
+	hasPanicked := func() (res bool) {
+		defer func() {
+			if recover() != nil {
+				res = true
+			}
+		}()
+		NewGenerator(aes.NewCipher)
+		return false
+	}()
+	if !hasPanicked {
+		t.Error("NewGenerator did not panic")
+	}
+}
+
+func TestReseedPools(t *testing.T) {
+	for count := uint64(1); count < 1000; count++ {
+		k := reseedPools(count, numPools)
+		for i := 0; i < numPools; i++ {
+			used := count%(uint64(1)<<uint(i)) == 0
+			if used != (i < k) {
+				t.Fatalf("reseed %d: wrong use of pool %d", count, i)
+			}
+		}
+	}
+	if k := reseedPools(1<<63, 64); k != 64 {
+		t.Errorf("wrong number of pools %d", k)
+	}
+}
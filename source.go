@@ -0,0 +1,142 @@
+// source.go - handles for entropy sources
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package fortuna
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrClosed is returned by the methods of a Source after the Source or
+// the Accumulator it belongs to has been closed.
+var ErrClosed = errors.New("entropy source closed")
+
+// Source is a handle for submitting entropy from one source to the
+// entropy pools of an Accumulator.  Sources are allocated using
+// Accumulator.NewSource().  Every Source has its own source number, and
+// the events submitted through a Source are spread evenly over the
+// entropy pools.
+//
+// It is safe to use a Source concurrently from different goroutines.
+type Source struct {
+	acc  *Accumulator
+	id   uint64
+	name string
+
+	mutex  sync.Mutex
+	seq    uint
+	last   time.Time
+	events uint64
+	closed bool
+}
+
+// NewSource allocates a new entropy source.  The name is only used for
+// diagnostics and need not be unique.  If the maximal number of
+// sources is already in use, ErrTooManySources is returned, see
+// WithMaxSources().  The Source must be closed once it is no longer
+// needed, to free its slot.
+func (acc *Accumulator) NewSource(name string) (*Source, error) {
+	id, err := acc.allocateSource()
+	if err != nil {
+		return nil, err
+	}
+	s := &Source{
+		acc:  acc,
+		id:   id,
+		name: name,
+		last: time.Now(),
+	}
+	return s, nil
+}
+
+// Name returns the name given when the Source was allocated.
+func (s *Source) Name() string {
+	return s.name
+}
+
+// Events returns the number of events which have been successfully
+// submitted through the Source.
+func (s *Source) Events() uint64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.events
+}
+
+// Add submits data to the entropy pools.  The data should be derived
+// from quantities which change between calls and which cannot be
+// (completely) known to an attacker.  If data is longer than 32 bytes,
+// the data is hashed using the hash function of the entropy pools (see
+// WithHash()) and the hash is submitted instead.  If the Source or the
+// Accumulator has been closed, ErrClosed is returned.
+func (s *Source) Add(data []byte) error {
+	if len(data) > 32 {
+		hash := s.acc.newHash()
+		hash.Write(data)
+		digest := hash.Sum(nil)
+		defer wipe(digest)
+		data = digest
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.add(data)
+}
+
+// AddTime submits the time elapsed between the previous call to
+// AddTime (or the allocation of the Source) and t to the entropy
+// pools.  The times should be chosen such that they cannot be
+// (completely) known to an attacker, for example the arrival times of
+// network packets.  If the Source or the Accumulator has been closed,
+// ErrClosed is returned.
+func (s *Source) AddTime(t time.Time) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	dt := t.Sub(s.last)
+	s.last = t
+	buf := int64ToBytes(int64(dt))
+	defer wipe(buf)
+	return s.add(buf)
+}
+
+// add submits one event.  The caller must hold s.mutex.
+func (s *Source) add(data []byte) error {
+	if s.closed {
+		return ErrClosed
+	}
+	err := s.acc.addRandomEvent(s.id, s.seq, data)
+	if err != nil {
+		return err
+	}
+	s.seq++
+	s.events++
+	return nil
+}
+
+// Close frees the slot used by the Source.  Afterwards, Add() and
+// AddTime() return ErrClosed.  Calling Close more than once is
+// harmless.
+func (s *Source) Close() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	s.acc.releaseSource()
+}
@@ -0,0 +1,118 @@
+// source_test.go - unit tests for source.go
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package fortuna
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSource(t *testing.T) {
+	acc, err := NewAccumulatorWithOptions(WithNumPools(1),
+		WithHash(sha512.New))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer acc.Close()
+
+	src, err := acc.NewSource("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if src.Name() != "test" {
+		t.Errorf("wrong name %q", src.Name())
+	}
+
+	// long data is hashed with the pool hash before it is added to
+	// the pool
+	long := make([]byte, 100)
+	err = src.Add(long)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha512.Sum512(long)
+	h := sha512.New()
+	h.Write([]byte{byte(src.id), sha512.Size})
+	h.Write(digest[:])
+	if !bytes.Equal(acc.pool[0].Sum(nil), h.Sum(nil)) {
+		t.Error("wrong pool contents")
+	}
+
+	err = src.AddTime(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if src.Events() != 2 {
+		t.Errorf("wrong event count %d", src.Events())
+	}
+
+	src.Close()
+	src.Close()
+	if err := src.Add([]byte{1}); !errors.Is(err, ErrClosed) {
+		t.Errorf("Add after Close: wrong error %v", err)
+	}
+	if err := src.AddTime(time.Now()); !errors.Is(err, ErrClosed) {
+		t.Errorf("AddTime after Close: wrong error %v", err)
+	}
+	if src.Events() != 2 {
+		t.Errorf("wrong event count %d", src.Events())
+	}
+	if acc.activeSources != 0 {
+		t.Errorf("source slot not released: %d active", acc.activeSources)
+	}
+}
+
+func TestSourceAfterAccumulatorClose(t *testing.T) {
+	acc, err := NewAccumulatorWithOptions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := acc.NewSource("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dataSink := acc.NewEntropyDataSink()
+	timeSink := acc.NewEntropyTimeStampSink()
+	acc.Close()
+
+	if err := src.Add([]byte{1}); !errors.Is(err, ErrClosed) {
+		t.Errorf("Add: wrong error %v", err)
+	}
+	if err := src.AddTime(time.Now()); !errors.Is(err, ErrClosed) {
+		t.Errorf("AddTime: wrong error %v", err)
+	}
+
+	// sending to the channels must not block after Close
+	done := make(chan bool)
+	go func() {
+		for i := 0; i < 10*channelBufferSize; i++ {
+			dataSink <- []byte{1, 2, 3}
+			timeSink <- time.Now()
+		}
+		close(dataSink)
+		close(timeSink)
+		done <- true
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Error("sending to a sink blocked after Close")
+	}
+}
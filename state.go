@@ -0,0 +1,265 @@
+// state.go - saving and restoring the state of a Fortuna generator
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package fortuna
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+)
+
+// The serialised generator state has the following format:
+//
+//     magic     4 bytes, stateMagic
+//     version   1 byte, stateVersion or stateVersionFKE
+//     cipher    cipherIDSize bytes, see cipherID()
+//     keyLen    1 byte
+//     key       keyLen bytes
+//     ctrLen    1 byte
+//     counter   ctrLen bytes
+//     bufLen    2 bytes, big-endian, only for stateVersionFKE
+//     buffer    bufLen bytes, only for stateVersionFKE
+//     checksum  sha256.Size bytes, SHA-256 of all preceding bytes
+//
+// Version stateVersionFKE is used for generators in fast-key-erasure
+// mode; the buffer holds the unused output of the current batch.
+const (
+	stateMagic      = "FGen"
+	stateVersion    = 1
+	stateVersionFKE = 2
+	cipherIDSize    = 16
+)
+
+// Error codes relating to serialised generator states.
+var (
+	// ErrInvalidState indicates that a serialised generator state is
+	// corrupted or has an unsupported format.
+	ErrInvalidState = errors.New("invalid generator state")
+
+	// ErrCipherMismatch indicates that a serialised generator state
+	// was created by a generator using a different block cipher.
+	ErrCipherMismatch = errors.New("generator state uses a different cipher")
+)
+
+// cipherID computes a short fingerprint of the block cipher used by
+// the generator.  The fingerprint is obtained by encrypting a fixed
+// block of data with a fixed key, so that different ciphers (and
+// different block sizes) lead to different fingerprints with
+// overwhelming probability.
+func (gen *Generator) cipherID() ([]byte, error) {
+	key := make([]byte, gen.keyLen)
+	for i := range key {
+		key[i] = byte(i)
+	}
+	c, err := gen.newCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	blockSize := c.BlockSize()
+	in := make([]byte, blockSize)
+	copy(in, "Fortuna cipher identification")
+	out := make([]byte, blockSize)
+	c.Encrypt(out, in)
+
+	hash := sha256.New()
+	hash.Write([]byte{byte(blockSize)})
+	hash.Write(out)
+	return hash.Sum(nil)[:cipherIDSize], nil
+}
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface.
+// The returned data describes the exact position of the generator in
+// its output stream: a generator restored using UnmarshalBinary()
+// produces the same output as the original generator would have
+// produced.  For generators in fast-key-erasure mode, the data also
+// includes the buffered output which has not yet been handed out.
+//
+// The serialised state contains the generator key in plain text and
+// must be kept as secret as the generator output itself.  Anybody who
+// obtains a copy can predict all output of the generator until it is
+// next reseeded with unknown data.  Restoring the same state twice
+// makes the generator repeat its output, which is disastrous in
+// cryptographic applications.
+func (gen *Generator) MarshalBinary() ([]byte, error) {
+	if gen.destroyed {
+		return nil, ErrDestroyed
+	}
+	id, err := gen.cipherID()
+	if err != nil {
+		return nil, err
+	}
+
+	// The buffer is allocated with its final size, so that no stale
+	// copies of the key are left behind when the buffer grows.
+	size := len(stateMagic) + 1 + len(id) + 1 + len(gen.key) +
+		1 + len(gen.counter) + sha256.Size
+	if gen.fastKeyErasure {
+		size += 2 + len(gen.fkeBuf) - gen.fkePos
+	}
+	buf := bytes.NewBuffer(make([]byte, 0, size))
+	buf.WriteString(stateMagic)
+	if gen.fastKeyErasure {
+		buf.WriteByte(stateVersionFKE)
+	} else {
+		buf.WriteByte(stateVersion)
+	}
+	buf.Write(id)
+	buf.WriteByte(byte(len(gen.key)))
+	buf.Write(gen.key)
+	buf.WriteByte(byte(len(gen.counter)))
+	buf.Write(gen.counter)
+	if gen.fastKeyErasure {
+		unused := gen.fkeBuf[gen.fkePos:]
+		buf.WriteByte(byte(len(unused) >> 8))
+		buf.WriteByte(byte(len(unused)))
+		buf.Write(unused)
+	}
+
+	checksum := sha256.Sum256(buf.Bytes())
+	buf.Write(checksum[:])
+
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface.
+// It restores a generator state previously saved by MarshalBinary().
+// The receiver must have been allocated using NewGenerator() (or
+// NewGeneratorE()), with the same NewCipher function as the generator
+// which produced the data.  If the data was created using a
+// different block cipher, ErrCipherMismatch is returned.  If the data
+// is corrupted, ErrInvalidState is returned.  In case of errors, the
+// generator state is left unchanged.  The generator is switched to or
+// from fast-key-erasure mode (see FastKeyErasure()), as described by
+// the data.
+//
+// The checksum included in the data only protects against accidental
+// corruption; serialised states must be stored in a location which
+// attackers can neither read nor modify.
+func (gen *Generator) UnmarshalBinary(data []byte) error {
+	if gen.destroyed {
+		return ErrDestroyed
+	}
+	if gen.newCipher == nil {
+		return ErrCipherMismatch
+	}
+
+	n := len(data) - sha256.Size
+	if n < 0 {
+		return ErrInvalidState
+	}
+	checksum := sha256.Sum256(data[:n])
+	if !bytes.Equal(checksum[:], data[n:]) {
+		return ErrInvalidState
+	}
+	body := data[:n]
+
+	if !bytes.HasPrefix(body, []byte(stateMagic)) {
+		return ErrInvalidState
+	}
+	body = body[len(stateMagic):]
+	if len(body) < 1 ||
+		body[0] != stateVersion && body[0] != stateVersionFKE {
+		return ErrInvalidState
+	}
+	fke := body[0] == stateVersionFKE
+	body = body[1:]
+
+	if len(body) < cipherIDSize {
+		return ErrInvalidState
+	}
+	id, err := gen.cipherID()
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(id, body[:cipherIDSize]) {
+		return ErrCipherMismatch
+	}
+	body = body[cipherIDSize:]
+
+	key, body, ok := readLengthPrefixed(body)
+	if !ok || len(key) != gen.keyLen {
+		return ErrInvalidState
+	}
+	counter, body, ok := readLengthPrefixed(body)
+	if !ok {
+		return ErrInvalidState
+	}
+	var unused []byte
+	if fke {
+		if len(body) < 2 {
+			return ErrInvalidState
+		}
+		n := int(body[0])<<8 | int(body[1])
+		body = body[2:]
+		if len(body) < n {
+			return ErrInvalidState
+		}
+		unused, body = body[:n], body[n:]
+	}
+	if len(body) != 0 {
+		return ErrInvalidState
+	}
+
+	// The mode is taken from the data, all other settings from gen.
+	settings := *gen
+	settings.fastKeyErasure = fke
+	restored, err := settings.newUnseeded()
+	if err != nil {
+		return err
+	}
+	restored.healthErr = gen.healthErr
+	if fke {
+		if len(unused) > len(restored.fkeBuf)-restored.keyLen {
+			restored.mem.free()
+			return ErrInvalidState
+		}
+		restored.fkePos = len(restored.fkeBuf) - len(unused)
+		copy(restored.fkeBuf[restored.fkePos:], unused)
+	}
+	if len(counter) != len(restored.counter) {
+		restored.mem.free()
+		return ErrInvalidState
+	}
+	err = restored.trySetKey(key)
+	if err != nil {
+		restored.mem.free()
+		return err
+	}
+	copy(restored.counter, counter)
+
+	wipe(gen.key)
+	wipe(gen.prevBlock)
+	wipe(gen.fkeBuf)
+	gen.mem.free()
+	*gen = *restored
+	return nil
+}
+
+// readLengthPrefixed splits a field, prefixed by a one-byte length,
+// from the start of data.
+func readLengthPrefixed(data []byte) (field, rest []byte, ok bool) {
+	if len(data) < 1 {
+		return nil, nil, false
+	}
+	n := int(data[0])
+	data = data[1:]
+	if len(data) < n {
+		return nil, nil, false
+	}
+	return data[:n], data[n:], true
+}
@@ -0,0 +1,125 @@
+// state_test.go - unit tests for state.go
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package fortuna
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding"
+	"testing"
+)
+
+// reversedAES is AES with the byte order of the input block reversed.
+// This gives a valid block cipher which differs from AES.
+type reversedAES struct {
+	cipher.Block
+}
+
+func newReversedAES(key []byte) (cipher.Block, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return reversedAES{block}, nil
+}
+
+func (c reversedAES) Encrypt(dst, src []byte) {
+	n := c.BlockSize()
+	tmp := make([]byte, n)
+	for i := 0; i < n; i++ {
+		tmp[i] = src[n-1-i]
+	}
+	c.Block.Encrypt(dst, tmp)
+}
+
+func TestMarshalBinary(t *testing.T) {
+	gen := NewGenerator(aes.NewCipher)
+	gen.Seed(42)
+	gen.PseudoRandomData(1000)
+
+	state, err := gen.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := gen.PseudoRandomData(1000)
+
+	restored := NewGenerator(aes.NewCipher)
+	err = restored.UnmarshalBinary(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := restored.PseudoRandomData(1000)
+	if !bytes.Equal(out, expected) {
+		t.Error("restored generator produces wrong output")
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	gen := NewGenerator(aes.NewCipher)
+	state, err := gen.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other := NewGenerator(newReversedAES)
+	key := append([]byte{}, other.key...)
+	err = other.UnmarshalBinary(state)
+	if err != ErrCipherMismatch {
+		t.Errorf("wrong cipher not detected: %v", err)
+	}
+	if !bytes.Equal(other.key, key) {
+		t.Error("failed UnmarshalBinary changed the generator state")
+	}
+
+	err = (&Generator{}).UnmarshalBinary(state)
+	if err != ErrCipherMismatch {
+		t.Errorf("missing cipher not detected: %v", err)
+	}
+
+	for i := 0; i < len(state); i++ {
+		corrupted := append([]byte{}, state...)
+		corrupted[i] ^= 1
+		err = gen.UnmarshalBinary(corrupted)
+		if err != ErrInvalidState {
+			t.Errorf("corruption at byte %d not detected: %v", i, err)
+		}
+	}
+	for _, n := range []int{0, 1, len(state) - 1} {
+		err = gen.UnmarshalBinary(state[:n])
+		if err != ErrInvalidState {
+			t.Errorf("truncation to %d bytes not detected: %v", n, err)
+		}
+	}
+
+	// unknown versions must be rejected, even with a valid checksum
+	body := append([]byte{}, state[:len(state)-sha256.Size]...)
+	body[len(stateMagic)] = stateVersion + 1
+	checksum := sha256.Sum256(body)
+	err = gen.UnmarshalBinary(append(body, checksum[:]...))
+	if err != ErrInvalidState {
+		t.Errorf("unknown version not detected: %v", err)
+	}
+}
+
+// compile-time test: Generator implements the encoding.BinaryMarshaler
+// and encoding.BinaryUnmarshaler interfaces
+var (
+	_ encoding.BinaryMarshaler   = &Generator{}
+	_ encoding.BinaryUnmarshaler = &Generator{}
+)
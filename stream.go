@@ -0,0 +1,198 @@
+// stream.go - a seekable stream of pseudo random data
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package fortuna
+
+import (
+	"crypto/cipher"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Error codes relating to the Stream type.
+var (
+	// ErrInvalidSeek indicates that a Stream was positioned outside the
+	// range 0, 1, ..., 2^63-1 or that an invalid whence value was used.
+	ErrInvalidSeek = errors.New("invalid stream position")
+)
+
+// Stream is a reproducible stream of pseudo random bytes which allows
+// random access.  Any part of the stream can be reached using the
+// Seek() method, without generating the preceding data, and the
+// returned bytes are the same as the ones obtained by reading the
+// stream sequentially.  This is useful for procedural generation and
+// for parallel simulations, where chunk n of the data must be
+// reproduced independently of chunks 0, ..., n-1.
+//
+// The stream is divided into chunks of 2^16 cipher blocks (fewer for
+// ciphers with 64 bit blocks, see AllowSmallBlocks()), and every chunk
+// is encrypted using its own key.  The chunk keys are derived from the
+// stream key by encrypting the chunk index, so that no key is used for
+// more output than a Generator would produce between two rekeyings.
+// In contrast to the Generator, the stream key is never replaced:
+// knowledge of the Stream state reveals all past and future output.
+// Streams are therefore not suitable for generating cryptographic
+// keys.
+//
+// A Stream is not safe for use with concurrent access.
+type Stream struct {
+	newCipher   NewCipher
+	master      cipher.Block
+	blockSize   int
+	keyLen      int
+	chunkBlocks int64
+	pos         int64
+
+	chunk       int64
+	chunkCipher cipher.Block
+
+	counter []byte
+	block   []byte
+	keyBuf  []byte
+}
+
+// NewStream returns a new Stream, using key material taken from the
+// output of the generator.  The generator is advanced in the process,
+// so that a seeded generator always returns the same sequence of
+// streams.  If the generator has not been seeded, ErrNotSeeded is
+// returned.  If the block cipher cannot be initialised, an error
+// wrapping ErrCipherInit is returned.
+func (gen *Generator) NewStream() (*Stream, error) {
+	key := make([]byte, gen.keyLen)
+	defer wipe(key)
+	err := gen.fill(key)
+	if err != nil {
+		return nil, err
+	}
+
+	master, err := gen.newCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCipherInit, err)
+	}
+	blockSize := master.BlockSize()
+	stream := &Stream{
+		newCipher:   gen.newCipher,
+		master:      master,
+		blockSize:   blockSize,
+		keyLen:      gen.keyLen,
+		chunkBlocks: int64(gen.rekeyBlocks),
+		chunk:       -1,
+		counter:     make([]byte, blockSize),
+		block:       make([]byte, blockSize),
+		keyBuf:      make([]byte, gen.numBlocks(uint(gen.keyLen))*uint(blockSize)),
+	}
+	return stream, nil
+}
+
+// BlockSize returns the block size of the underlying cipher.  Block n
+// of the stream starts at byte offset n*BlockSize().
+func (s *Stream) BlockSize() int {
+	return s.blockSize
+}
+
+// setCounter stores x in the counter block, least-significant byte
+// first.
+func (s *Stream) setCounter(x uint64) {
+	for i := range s.counter {
+		s.counter[i] = byte(x)
+		x >>= 8
+	}
+}
+
+// selectChunk sets up the cipher used to generate chunk j.
+func (s *Stream) selectChunk(j int64) error {
+	if s.chunk == j {
+		return nil
+	}
+
+	k := uint64(len(s.keyBuf) / s.blockSize)
+	for i := uint64(0); i < k; i++ {
+		s.setCounter(uint64(j)*k + i)
+		s.master.Encrypt(s.keyBuf[int(i)*s.blockSize:], s.counter)
+	}
+	c, err := s.newCipher(s.keyBuf[:s.keyLen])
+	wipe(s.keyBuf)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrCipherInit, err)
+	}
+	s.chunk = j
+	s.chunkCipher = c
+	return nil
+}
+
+// Read implements the io.Reader interface.  Read fills p with the
+// stream data starting at the current position and advances the
+// position by len(p).  Read only returns an error if the block cipher
+// cannot be initialised, or if the position would exceed 2^63-1.
+func (s *Stream) Read(p []byte) (int, error) {
+	if int64(len(p)) > math.MaxInt64-s.pos {
+		return 0, ErrInvalidSeek
+	}
+
+	bs := int64(s.blockSize)
+	chunkSize := s.chunkBlocks * bs
+	n := 0
+	for n < len(p) {
+		err := s.selectChunk(s.pos / chunkSize)
+		if err != nil {
+			return n, err
+		}
+
+		offset := s.pos % chunkSize
+		s.setCounter(uint64(offset / bs))
+		skip := int(offset % bs)
+		todo := len(p) - n
+		if skip == 0 && todo >= s.blockSize {
+			s.chunkCipher.Encrypt(p[n:], s.counter)
+			todo = s.blockSize
+		} else {
+			s.chunkCipher.Encrypt(s.block, s.counter)
+			if todo > s.blockSize-skip {
+				todo = s.blockSize - skip
+			}
+			copy(p[n:n+todo], s.block[skip:])
+			wipe(s.block)
+		}
+		n += todo
+		s.pos += int64(todo)
+	}
+	return n, nil
+}
+
+// Seek implements the io.Seeker interface.  Offsets are measured in
+// bytes; use BlockSize() to convert block numbers into byte offsets.
+// Since the stream has no end, io.SeekEnd is not supported.
+func (s *Stream) Seek(offset int64, whence int) (int64, error) {
+	var pos int64
+	switch whence {
+	case io.SeekStart:
+		pos = offset
+	case io.SeekCurrent:
+		if offset > 0 && s.pos > math.MaxInt64-offset {
+			return s.pos, ErrInvalidSeek
+		}
+		pos = s.pos + offset
+	default:
+		return s.pos, ErrInvalidSeek
+	}
+	if pos < 0 {
+		return s.pos, ErrInvalidSeek
+	}
+	s.pos = pos
+	return pos, nil
+}
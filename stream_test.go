@@ -0,0 +1,146 @@
+// stream_test.go - unit tests for stream.go
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package fortuna
+
+import (
+	"bytes"
+	"crypto/aes"
+	"io"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func newTestStream(t *testing.T, seed int64) *Stream {
+	gen := NewGenerator(aes.NewCipher)
+	gen.Seed(seed)
+	stream, err := gen.NewStream()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return stream
+}
+
+func TestStreamSeek(t *testing.T) {
+	stream := newTestStream(t, 1)
+	chunkSize := maxBlocks * stream.BlockSize()
+
+	// read 2.5 chunks sequentially, in pieces of varying size
+	n := 5 * chunkSize / 2
+	sequential := make([]byte, n)
+	for pos := 0; pos < n; {
+		k := 1 + pos%37
+		if pos+k > n {
+			k = n - pos
+		}
+		m, err := stream.Read(sequential[pos : pos+k])
+		if err != nil || m != k {
+			t.Fatalf("Read failed: %d %v", m, err)
+		}
+		pos += k
+	}
+
+	// read back random pieces, including ones crossing chunk boundaries
+	src := rand.New(rand.NewSource(1))
+	starts := []int{0, chunkSize - 5, 2*chunkSize - 16, n - 100}
+	for i := 0; i < 100; i++ {
+		starts = append(starts, src.Intn(n-100))
+	}
+	for _, start := range starts {
+		pos, err := stream.Seek(int64(start), io.SeekStart)
+		if err != nil || pos != int64(start) {
+			t.Fatalf("Seek failed: %d %v", pos, err)
+		}
+		buf := make([]byte, 100)
+		stream.Read(buf)
+		if !bytes.Equal(buf, sequential[start:start+100]) {
+			t.Errorf("wrong data at offset %d", start)
+		}
+	}
+
+	pos, err := stream.Seek(-50, io.SeekCurrent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 50)
+	stream.Read(buf)
+	if !bytes.Equal(buf, sequential[pos:pos+50]) {
+		t.Error("wrong data after relative seek")
+	}
+}
+
+func TestStreamReproducible(t *testing.T) {
+	a := newTestStream(t, 7)
+	b := newTestStream(t, 7)
+	c := newTestStream(t, 8)
+
+	blockOffset := int64(1 << 40)
+	for _, s := range []*Stream{a, b, c} {
+		s.Seek(blockOffset*int64(s.BlockSize()), io.SeekStart)
+	}
+	x := make([]byte, 64)
+	y := make([]byte, 64)
+	z := make([]byte, 64)
+	a.Read(x)
+	b.Read(y)
+	c.Read(z)
+	if !bytes.Equal(x, y) {
+		t.Error("streams from identical seeds differ")
+	}
+	if bytes.Equal(x, z) {
+		t.Error("streams from different seeds coincide")
+	}
+}
+
+func TestStreamSeekErrors(t *testing.T) {
+	stream := newTestStream(t, 1)
+
+	if _, err := stream.Seek(-1, io.SeekStart); err != ErrInvalidSeek {
+		t.Error("negative position not detected")
+	}
+	if _, err := stream.Seek(0, io.SeekEnd); err != ErrInvalidSeek {
+		t.Error("io.SeekEnd not rejected")
+	}
+	stream.Seek(math.MaxInt64-10, io.SeekStart)
+	if _, err := stream.Seek(20, io.SeekCurrent); err != ErrInvalidSeek {
+		t.Error("overflow not detected")
+	}
+	if _, err := stream.Read(make([]byte, 20)); err != ErrInvalidSeek {
+		t.Error("overflow during Read not detected")
+	}
+
+	gen := NewGenerator(aes.NewCipher)
+	gen.reset()
+	if _, err := gen.NewStream(); err != ErrNotSeeded {
+		t.Errorf("unseeded generator not detected: %v", err)
+	}
+}
+
+func BenchmarkStreamRead(b *testing.B) {
+	gen := NewGenerator(aes.NewCipher)
+	stream, _ := gen.NewStream()
+	buffer := make([]byte, 1024)
+
+	b.SetBytes(int64(len(buffer)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		stream.Read(buffer)
+	}
+}
+
+// compile-time test: Stream implements the io.ReadSeeker interface
+var _ io.ReadSeeker = &Stream{}
@@ -0,0 +1,141 @@
+// wipe_test.go - check that transient secrets are wiped after use
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package fortuna
+
+import (
+	"crypto/aes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// The seed buffers of an Accumulator are taken from acc.poolSeed and
+// acc.fileSeed, if these are large enough.  The tests below install
+// ordinary buffers there, so that the contents can be inspected after
+// use.
+
+func TestWipeReseedSeed(t *testing.T) {
+	acc, err := NewAccumulatorWithOptions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer acc.Close()
+	acc.poolSeed = make([]byte, len(acc.pool)*acc.pool[0].Size())
+
+	acc.addRandomEvent(0, 0, []byte("secret entropy, 32 bytes long..."))
+	acc.RandomData(10)
+	if acc.reseedCount != 1 {
+		t.Fatal("generator not reseeded")
+	}
+	if !isZero(acc.poolSeed) {
+		t.Error("reseed seed not wiped")
+	}
+}
+
+func TestWipeTearDownData(t *testing.T) {
+	acc, err := NewAccumulatorWithOptions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, len(acc.pool)*acc.pool[0].Size())
+	acc.poolSeed = buf
+	err = acc.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isZero(buf) {
+		t.Error("pool data not wiped")
+	}
+}
+
+func TestWipeSeedFileBuffer(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+	seedFileName := filepath.Join(tempDir, "seed")
+
+	acc, err := NewAccumulatorWithOptions(WithSeedFile(seedFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer acc.Close()
+	buf := make([]byte, seedFileSize)
+	acc.fileSeed = buf
+
+	// read the seed file written by the constructor, and write a new one
+	err = acc.updateSeedFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isZero(buf) {
+		t.Error("seed file buffer not wiped by updateSeedFile()")
+	}
+
+	err = acc.writeSeedFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isZero(buf) {
+		t.Error("seed file buffer not wiped by writeSeedFile()")
+	}
+	data, err := ioutil.ReadFile(seedFileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != seedFileSize || isZero(data) {
+		t.Error("seed file not written")
+	}
+}
+
+func TestWipeOldKey(t *testing.T) {
+	gen := NewGenerator(aes.NewCipher)
+	gen.Seed(1)
+	oldKey := gen.key
+	gen.Reseed([]byte{1, 2, 3})
+	gen.PseudoRandomData(100)
+	if &oldKey[0] != &gen.key[0] {
+		t.Error("key moved to a new buffer, old key left behind")
+	}
+
+	// a change of key size replaces the buffer, so the old one must be
+	// wiped
+	gen.keyLen = 16
+	err := gen.trySetKey(make([]byte, 16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isZero(oldKey) {
+		t.Error("old key not wiped")
+	}
+}
+
+func TestMarshalNoStaleCopies(t *testing.T) {
+	gen := NewGenerator(aes.NewCipher, FastKeyErasure())
+	gen.Seed(1)
+	gen.PseudoRandomData(10)
+	data, err := gen.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != cap(data) {
+		t.Errorf("state buffer was reallocated: len %d, cap %d",
+			len(data), cap(data))
+	}
+}